@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/they4kman/gosweep/game"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var configPath string
+var configProfile string
+
+// Names of the flags passed on the command line, as opposed to those set from
+// the config file (both of which are marked as Changed)
+var commandLineFlags = make(map[string]bool)
+
+// configSettings holds the settings from a config file, or one of its profiles.
+// Settings are named after the flags of the root command (e.g. width, mines,
+// tick-rate), with key bindings and colors in sections of their own.
+type configSettings struct {
+	Flags  map[string]interface{} `yaml:",inline"`
+	Keys   map[string]interface{} `yaml:"keys"`
+	Colors map[string]string      `yaml:"colors"`
+
+	// Named sets of settings, chosen with --profile, which override those
+	// at the top level
+	Profiles map[string]*configSettings `yaml:"profiles"`
+}
+
+// Flags which may not be set from a config file
+var unconfigurableFlags = map[string]bool{
+	"help":    true,
+	"config":  true,
+	"profile": true,
+}
+
+// defaultConfigPath returns the path of the config file read when --config is
+// not passed (~/.config/gosweep/config.yaml on Linux)
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gosweep", "config.yaml")
+}
+
+// applyConfigFile loads the config file (and the profile, if chosen) into the
+// command's flags and the game config. Flags passed on the command line take
+// precedence over the file.
+func applyConfigFile(cmd *cobra.Command) error {
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		commandLineFlags[flag.Name] = true
+	})
+
+	path := configPath
+	if path == "" {
+		path = defaultConfigPath()
+		if _, err := os.Stat(path); path == "" || os.IsNotExist(err) {
+			if configProfile != "" {
+				return fmt.Errorf("--profile requires a config file, but %s does not exist", path)
+			}
+			return nil
+		}
+	}
+
+	in, err := readRegularFile(path)
+	if err != nil {
+		return err
+	}
+
+	settings := configSettings{}
+	if err := yaml.Unmarshal([]byte(in), &settings); err != nil {
+		return fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+
+	if configProfile != "" {
+		profile, isDefined := settings.Profiles[configProfile]
+		if !isDefined {
+			return fmt.Errorf("config file %s has no profile %q (profiles: %s)",
+				path, configProfile, strings.Join(settings.profileNames(), ", "))
+		}
+		if profile.Profiles != nil {
+			return fmt.Errorf("%s: profiles may not be nested (in profile %q)", path, configProfile)
+		}
+		settings.override(profile)
+	}
+
+	if err := settings.apply(cmd.Flags(), &gameConfig); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// override replaces settings with those of the profile
+func (settings *configSettings) override(profile *configSettings) {
+	if settings.Flags == nil {
+		settings.Flags = make(map[string]interface{})
+	}
+	for name, value := range profile.Flags {
+		settings.Flags[name] = value
+	}
+
+	if settings.Keys == nil {
+		settings.Keys = make(map[string]interface{})
+	}
+	for action, buttons := range profile.Keys {
+		settings.Keys[action] = buttons
+	}
+
+	if settings.Colors == nil {
+		settings.Colors = make(map[string]string)
+	}
+	for name, value := range profile.Colors {
+		settings.Colors[name] = value
+	}
+}
+
+func (settings *configSettings) profileNames() []string {
+	names := make([]string, 0, len(settings.Profiles))
+	for name := range settings.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apply sets each flag not already changed, along with the key bindings and
+// colors
+func (settings *configSettings) apply(flags *pflag.FlagSet, config *game.GameConfig) error {
+	names := make([]string, 0, len(settings.Flags))
+	for name := range settings.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := flags.Lookup(name)
+		if flag == nil || unconfigurableFlags[name] {
+			return fmt.Errorf("unknown setting %q", name)
+		}
+		if flag.Changed {
+			continue
+		}
+
+		values, err := configValues(settings.Flags[name])
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		for _, value := range values {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+
+	for actionName, rawButtons := range settings.Keys {
+		action := game.KeyAction(actionName)
+		if !isKeyAction(action) {
+			return fmt.Errorf("unknown key action %q", actionName)
+		}
+		buttonNames, err := configValues(rawButtons)
+		if err != nil {
+			return fmt.Errorf("keys: %s: %w", actionName, err)
+		}
+
+		buttons := make([]pixelgl.Button, 0, len(buttonNames))
+		for _, buttonName := range buttonNames {
+			button, err := game.ParseButton(buttonName)
+			if err != nil {
+				return fmt.Errorf("keys: %s: %w", actionName, err)
+			}
+			buttons = append(buttons, button)
+		}
+
+		config.KeyBindings[action] = buttons
+	}
+
+	for colorName, value := range settings.Colors {
+		color := config.Colors.Color(colorName)
+		if color == nil {
+			return fmt.Errorf("unknown color setting %q", colorName)
+		}
+		parsed, err := game.ParseColor(value)
+		if err != nil {
+			return fmt.Errorf("colors: %s: %w", colorName, err)
+		}
+
+		*color = parsed
+	}
+
+	return nil
+}
+
+func isKeyAction(action game.KeyAction) bool {
+	for _, known := range game.KeyActions {
+		if action == known {
+			return true
+		}
+	}
+	return false
+}
+
+// configValues converts a setting to the values to set its flag to: one for
+// each item of a list, or just the one otherwise
+func configValues(raw interface{}) ([]string, error) {
+	switch value := raw.(type) {
+	case nil:
+		return nil, fmt.Errorf("no value given")
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			switch item.(type) {
+			case []interface{}, map[interface{}]interface{}:
+				return nil, fmt.Errorf("lists may only hold plain values")
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	case map[interface{}]interface{}:
+		return nil, fmt.Errorf("expected a value or list of values")
+	default:
+		return []string{fmt.Sprint(value)}, nil
+	}
+}
+
+func init() {
+	rootCmd.Flags().StringVar(&configPath, "config", "", fmt.Sprintf(`Config file to read settings from (default %s).
+Settings are named after flags, and passing a flag overrides its setting`, defaultConfigPath()))
+	rootCmd.Flags().StringVar(&configProfile, "profile", "", "Named profile of settings to use from the config file (overrides its top-level settings)")
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/they4kman/gosweep/director/registry"
+	"github.com/they4kman/gosweep/game"
+	"os"
+	"text/tabwriter"
+)
+
+var directorsCmd = &cobra.Command{
+	Use:   "directors",
+	Short: "List the directors which may be chosen with --director, and their options",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for _, entry := range registry.Entries() {
+			fmt.Fprintf(table, "%s\t%s\n", entry.Name, entry.Description)
+
+			for _, option := range entry.Options {
+				defaultValue := "required"
+				if option.Default != "" {
+					defaultValue = "default: " + option.Default
+				}
+				fmt.Fprintf(table, "  --director-opt %s=…\t%s (%s)\n", option.Name, option.Description, defaultValue)
+			}
+		}
+		table.Flush()
+	},
+}
+
+// newDirector creates a director from its spec (see registry.NewFromSpec) and
+// any options given as <name>=<value>
+func newDirector(spec string, rawOptions []string) (game.Director, error) {
+	options := make(registry.Options, len(rawOptions))
+	for _, rawOption := range rawOptions {
+		name, value, err := registry.ParseOption(rawOption)
+		if err != nil {
+			return nil, err
+		}
+		options[name] = value
+	}
+
+	return registry.NewFromSpec(spec, options)
+}
+
+func init() {
+	rootCmd.AddCommand(directorsCmd)
+}
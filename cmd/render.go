@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/they4kman/gosweep/game"
+	"image/png"
+	"os"
+)
+
+var renderConfig = game.NewRenderConfig()
+var renderOutput string
+var renderFresh bool
+var renderAnnotate bool
+var renderDirectorSpec string
+var renderDirectorOptions []string
+
+var renderCmd = &cobra.Command{
+	Use:   "render <snapshot>",
+	Short: "Render a board snapshot to a PNG image, without opening a window",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in, err := readRegularFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := game.LoadSnapshot(in)
+		if err != nil {
+			return err
+		}
+
+		var director game.Director
+		if renderAnnotate {
+			if director, err = newDirector(renderDirectorSpec, renderDirectorOptions); err != nil {
+				return err
+			}
+		}
+
+		img, err := game.RenderSnapshot(snapshot, renderFresh, director, renderConfig)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(renderOutput)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		if err := png.Encode(file, img); err != nil {
+			return fmt.Errorf("unable to write %s: %w", renderOutput, err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "board.png", "Path to write the PNG image to")
+	renderCmd.Flags().IntVar(&renderConfig.Scale, "scale", renderConfig.Scale, "Factor to scale each cell up by")
+	renderCmd.Flags().BoolVar(&renderConfig.RevealMines, "reveal-mines", renderConfig.RevealMines, "Show the locations of all mines")
+	renderCmd.Flags().BoolVar(&renderFresh, "fresh", false, "Render the snapshot completely unrevealed")
+	renderCmd.Flags().BoolVar(&renderAnnotate, "annotate", false, "Overlay the actions the director would take next")
+	renderCmd.Flags().StringVar(&renderDirectorSpec, "director", "constraint", "Director to annotate the board with (run \"gosweep directors\" to list them)")
+	renderCmd.Flags().StringArrayVar(&renderDirectorOptions, "director-opt", nil, "Option to pass to the director, as <name>=<value> (may be repeated)")
+	renderCmd.Flags().Float64Var(&renderConfig.AnnotationAlpha, "annotation-alpha", renderConfig.AnnotationAlpha, "Transparency of annotations")
+
+	rootCmd.AddCommand(renderCmd)
+}
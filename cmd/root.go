@@ -5,18 +5,31 @@ import (
 	"github.com/faiface/pixel/pixelgl"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	"github.com/they4kman/gosweep/director/constraint"
 	"github.com/they4kman/gosweep/game"
 	"io"
+	"math"
 	"os"
+	"strings"
 	"time"
 )
 
 var gameConfig = game.NewGameConfig()
-var useDirector = false
+var presetName string
+var directorSpec string
+var directorOptions []string
 var savedSnapshotsDir string
 var snapshotToLoad string
+var shapeToLoad string
+var codeToLoad string
+var printCode bool
 var verbosity string
+var exportGIFPath string
+var exportGIFScale int
+var playHuge bool
+var dumpDirector bool
+
+// Director played with when --director is passed without naming one
+const defaultDirector = "constraint"
 
 var rootCmd = &cobra.Command{
 	Use:   "gosweep",
@@ -27,18 +40,104 @@ computer-driven playing.
 Run with no arguments to play manually
 	gosweep
 
-Use the director flag to make the computer play for you
-	gosweep -director
+Use the director flag to make the computer play for you (with the constraint
+director, unless another is named)
+	gosweep --director
+	gosweep --director random
+
+List the directors available, and their options, with
+	gosweep directors
+
+Use the edit flag to craft a board, and save it as a snapshot
+	gosweep -edit
+
+Settings may be kept in a config file, and named profiles of them chosen with
+	gosweep --profile expert
 `,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// A bare --director takes no value, so a director named after it with a
+		// space (rather than "=") is left as an argument
+		if len(args) == 1 && cmd.Flags().Changed("director") && directorSpec == defaultDirector {
+			return nil
+		}
+		return cobra.NoArgs(cmd, args)
+	},
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if useDirector {
-			gameConfig.Director = &constraint.Director{}
+		if len(args) == 1 {
+			directorSpec = args[0]
+		}
+
+		if err := applyConfigFile(cmd); err != nil {
+			return err
+		}
+		if gameConfig.Lives == 0 {
+			return fmt.Errorf("--lives must be at least 1")
+		}
+
+		if presetName != "" && !strings.EqualFold(presetName, game.CustomPreset) {
+			preset, err := game.LookupPreset(presetName)
+			if err != nil {
+				return err
+			}
+
+			// Size and mines passed alongside the preset take precedence. A
+			// preset passed on the command line overrides the config file,
+			// though, so only size and mines passed with it are kept.
+			isOverridden := func(name string) bool {
+				if commandLineFlags["preset"] {
+					return commandLineFlags[name]
+				}
+				return cmd.Flag(name).Changed
+			}
+			if !isOverridden("width") {
+				gameConfig.Width = preset.Width
+			}
+			if !isOverridden("height") {
+				gameConfig.Height = preset.Height
+			}
+			if !isOverridden("mines") {
+				gameConfig.NumMines = preset.NumMines
+			}
+		}
+
+		// A mine density from the config file yields to mines (or a preset)
+		// passed on the command line, as other settings do
+		if (commandLineFlags["mines"] || commandLineFlags["preset"]) && !commandLineFlags["mine-density"] {
+			gameConfig.MineDensity = math.NaN()
+		}
+
+		if directorSpec != "" {
+			director, err := newDirector(directorSpec, directorOptions)
+			if err != nil {
+				return err
+			}
+			gameConfig.Director = director
+		} else if len(directorOptions) > 0 {
+			return fmt.Errorf("--director-opt requires --director")
+		}
+
+		if dumpDirector {
+			if _, isInspectable := gameConfig.Director.(game.InspectableDirector); !isInspectable {
+				return fmt.Errorf("--dump-director requires a director which may be inspected (e.g. constraint)")
+			}
+			gameConfig.DirectorDump = os.Stdout
 		}
 
 		if !cmd.Flag("seed").Changed {
 			gameConfig.Seed = time.Now().UnixNano()
 		}
 
+		if gameConfig.Edit {
+			// Start editing from an empty board, unless mines were asked for
+			if !cmd.Flag("mines").Changed && !cmd.Flag("mine-density").Changed {
+				gameConfig.NumMines = 0
+			}
+			// Edit loaded snapshots as they were saved
+			if !cmd.Flag("load-fresh").Changed {
+				gameConfig.LoadSnapshotFresh = false
+			}
+		}
+
 		if savedSnapshotsDir != "" {
 			stat, err := os.Stat(savedSnapshotsDir)
 			if err != nil {
@@ -53,40 +152,136 @@ Use the director flag to make the computer play for you
 		}
 
 		if snapshotToLoad != "" {
-			stat, err := os.Stat(snapshotToLoad)
+			in, err := readRegularFile(snapshotToLoad)
 			if err != nil {
 				return err
-			} else if !stat.Mode().IsRegular() {
-				return fmt.Errorf("%s is not a valid file", snapshotToLoad)
 			}
 
-			file, err := os.Open(snapshotToLoad)
-			if err != nil {
+			var snapshot *game.BoardSnapshot
+			if snapshot, err = game.LoadSnapshot(in); err != nil {
 				return err
 			}
 
-			bytes, err := io.ReadAll(file)
+			gameConfig.Snapshot = snapshot
+		}
+
+		if shapeToLoad != "" {
+			in, err := readRegularFile(shapeToLoad)
 			if err != nil {
 				return err
 			}
 
-			var snapshot *game.BoardSnapshot
-			if snapshot, err = game.LoadSnapshot(string(bytes)); err != nil {
+			var shape *game.BoardShape
+			if shape, err = game.LoadShape(in); err != nil {
 				return err
 			}
 
-			gameConfig.Snapshot = snapshot
+			gameConfig.Shape = shape
+			gameConfig.Width, gameConfig.Height = shape.Width(), shape.Height()
+		}
+
+		if codeToLoad != "" {
+			if snapshotToLoad != "" || shapeToLoad != "" {
+				return fmt.Errorf("--load-code cannot be combined with --load or --shape")
+			}
+
+			code, err := game.ParseBoardCode(codeToLoad)
+			if err != nil {
+				return err
+			}
+			gameConfig.ApplyBoardCode(code)
+		}
+
+		// Huge boards are played with compact bitboards, which fit far more
+		if !playHuge && !gameConfig.Fullscreen && !game.BoardFits(gameConfig.Width, gameConfig.Height) {
+			return fmt.Errorf("the board is %dx%d, but at most %d cells are supported (or pass --huge to play it headlessly)",
+				gameConfig.Width, gameConfig.Height, game.MaxBoardCells)
 		}
 
 		return nil
 	},
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if printCode {
+			code, err := gameConfig.BoardCode()
+			if err != nil {
+				return err
+			}
+			fmt.Println(code)
+			return nil
+		}
+		if exportGIFPath != "" {
+			return exportGIF()
+		}
+		if playHuge {
+			return playHugeGame()
+		}
+
 		pixelgl.Run(func() {
 			game.Run(gameConfig)
 		})
+		return nil
 	},
 }
 
+func exportGIF() error {
+	if gameConfig.Director == nil {
+		return fmt.Errorf("--export-gif requires --director")
+	}
+
+	file, err := os.Create(exportGIFPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return game.ExportGIF(gameConfig, exportGIFScale, file)
+}
+
+func playHugeGame() error {
+	result, err := game.PlayHugeGame(gameConfig)
+	if err != nil {
+		return err
+	}
+
+	outcome := "Lost"
+	if result.State == game.Won {
+		outcome = "Won"
+	}
+
+	fmt.Printf("%s a %dx%d board (%d cells, %d mines)\n", outcome, result.Width, result.Height, result.NumCells, result.NumMines)
+	fmt.Printf("  Revealed: %d\n", result.NumRevealed)
+	fmt.Printf("  Flagged:  %d\n", result.NumFlagged)
+	fmt.Printf("  Strikes:  %d\n", result.NumStrikes)
+	fmt.Printf("  Guesses:  %d\n", result.NumGuesses)
+	fmt.Printf("  Generated in %s, solved in %s\n", result.GenerateDuration, result.SolveDuration)
+	if result.SnapshotPath != "" {
+		fmt.Printf("  Saved to %s\n", result.SnapshotPath)
+	}
+	return nil
+}
+
+func readRegularFile(path string) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	} else if !stat.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a valid file", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	bytes, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -103,6 +298,8 @@ func newGameModeValue(val game.GameMode, p *game.GameMode) *gameModeValue {
 
 var gameModes = map[string]game.GameMode{
 	"win7":    game.Win7,
+	"winxp":   game.WinXP,
+	"opening": game.Opening,
 	"classic": game.Classic,
 }
 
@@ -143,6 +340,12 @@ func init() {
 	// Ref: https://github.com/spf13/cobra/issues/291
 	rootCmd.Flags().Bool("help", false, "Help for this command")
 
+	rootCmd.Flags().StringVar(&presetName, "preset", "", `Difficulty preset, setting the board size and number of mines
+(overridden by --width, --height and --mines)
+ - beginner:     9x9, 10 mines
+ - intermediate: 16x16, 40 mines
+ - expert:       30x16, 99 mines
+ - custom:       as set by --width, --height and --mines`)
 	rootCmd.Flags().UintVarP(&gameConfig.Width, "width", "w", gameConfig.Width, "Width of game board, in cells")
 	rootCmd.Flags().UintVarP(&gameConfig.Height, "height", "h", gameConfig.Height, "Height of game board, in cells")
 	rootCmd.Flags().UintVarP(&gameConfig.NumMines, "mines", "m", gameConfig.NumMines, "Number of mines to place in the game board")
@@ -150,17 +353,51 @@ func init() {
 	rootCmd.Flags().Float64Var(&gameConfig.MineDensity, "mine-density", gameConfig.MineDensity, "Percentage of mines to cells in the board (overrides --mines)")
 	rootCmd.Flags().Var(newGameModeValue(game.Win7, &gameConfig.Mode), "mode", `Game mode, controlling behaviour of first click.
  - win7:    all cells surrounding the first-clicked cell are cleared of mines
+            (first click never loses, and reveals an opening)
+ - winxp:   a mine in the first-clicked cell is moved to the top-left-most free cell
             (first click never loses)
+ - opening: all cells within two of the first-clicked cell are cleared of mines
+            (first click never loses, and reveals a larger opening)
  - classic: mines are left as is
             (first click can lose the game)`)
-	rootCmd.Flags().BoolVarP(&useDirector, "director", "d", false, "Make the computer play")
+	rootCmd.Flags().UintVar(&gameConfig.Lives, "lives", gameConfig.Lives, "Number of mines which may be revealed before losing, at least 1 (mines revealed before then are flagged)")
+	rootCmd.Flags().BoolVar(&gameConfig.QuestionMarks, "question-marks", gameConfig.QuestionMarks, "Whether right-clicking a flag marks the cell with a \"?\" (use --question-marks=false to go straight back to unrevealed)")
+	rootCmd.Flags().BoolVar(&gameConfig.ChordOnClick, "chord-on-click", gameConfig.ChordOnClick, "Whether left-clicking a revealed number chords it (revealing its neighbors, if it has as many flags around it as its number), as a middle click or left+right click does")
+	rootCmd.Flags().BoolVar(&gameConfig.FlagChordOnRightClick, "flag-chord", gameConfig.FlagChordOnRightClick, "Whether right-clicking a revealed number flags all its unrevealed neighbors, if there are only as many as its number")
+	rootCmd.Flags().StringVarP(&directorSpec, "director", "d", "", `Make the computer play, with the named director (the constraint director, if
+--director is passed alone). Options may follow a colon (e.g. name:option=value),
+or be passed with --director-opt. Run "gosweep directors" to list directors and
+their options`)
+	rootCmd.Flags().Lookup("director").NoOptDefVal = defaultDirector
+	rootCmd.Flags().StringArrayVar(&directorOptions, "director-opt", nil, "Option to pass to the director, as <name>=<value> (may be repeated)")
+	rootCmd.Flags().BoolVar(&dumpDirector, "dump-director", false, "Print the director's state each step, to debug it (press I to print it, and highlight what it knows of the hovered cell)")
 	rootCmd.Flags().DurationVar(&gameConfig.DirectorTickRate, "tick-rate", gameConfig.DirectorTickRate, "Make the computer play")
+	rootCmd.Flags().Float64Var(&gameConfig.AnnotationBaseAlpha, "annotation-alpha", gameConfig.AnnotationBaseAlpha, "Transparency of director annotations when first displayed")
+	rootCmd.Flags().DurationVar(&gameConfig.AnnotationDuration, "annotation-duration", gameConfig.AnnotationDuration, "Total time each director annotation is displayed")
 	rootCmd.Flags().Int64Var(&gameConfig.Seed, "seed", 1, "Initial seed to feed into random number generator")
 
 	rootCmd.Flags().StringVar(&savedSnapshotsDir, "save-snapshots-to", "", "Directory to save endgame board snapshots to")
 	rootCmd.Flags().StringVar(&snapshotToLoad, "load", "", "Board snapshot to load and play")
+	rootCmd.Flags().StringVar(&shapeToLoad, "shape", "", `Text file describing the shape of the board (overrides --width and --height).
+Each line is a row of cells; spaces and '-' are left out of the board`)
+	rootCmd.Flags().StringVar(&codeToLoad, "load-code", "", "Board code to load and play, as shared by another player (see --print-code)")
+	rootCmd.Flags().BoolVar(&printCode, "print-code", false, `Print the code of the board, which may be shared and played with --load-code,
+instead of playing it (codes of boards loaded with --load include every mine)`)
 	rootCmd.Flags().BoolVar(&gameConfig.LoadSnapshotFresh, "load-fresh", gameConfig.LoadSnapshotFresh, "Whether to load the specified snapshot completely unrevealed")
 
+	rootCmd.Flags().BoolVar(&gameConfig.Edit, "edit", gameConfig.Edit, `Open the board in the editor, to craft a snapshot.
+ - left click:         toggle mine
+ - shift + left click: toggle revealed
+ - right click:        toggle flag
+ - S / [Save]:         save snapshot (into --save-snapshots-to, or the current directory)
+ - Enter:              play the edited board`)
+
+	rootCmd.Flags().StringVar(&exportGIFPath, "export-gif", "", "Play a whole director game without opening a window, and save it as an animated GIF to this path (frames last --tick-rate)")
+	rootCmd.Flags().IntVar(&exportGIFScale, "gif-scale", 1, "Factor to scale each cell of the exported GIF up by")
+	rootCmd.Flags().BoolVar(&playHuge, "huge", false, `Play a whole game without opening a window, using a compact board fit for
+tens of millions of cells, and print a summary. The game is played by a
+simple built-in solver, rather than the director.`)
+
 	rootCmd.PersistentFlags().StringVarP(&verbosity, "verbosity", "v", logrus.WarnLevel.String(), "Log level (debug, info, warn, error, fatal, panic")
 
 	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"github.com/they4kman/gosweep/game"
+	"github.com/they4kman/gosweep/tournament"
+	"os"
+	"runtime"
+)
+
+var tournamentConfig = tournament.Config{
+	FirstSeed:   1,
+	NumSeeds:    50,
+	Lives:       1,
+	Parallelism: runtime.NumCPU(),
+}
+var tournamentBoards []string
+var tournamentFormat string
+var tournamentOutput string
+
+var tournamentCmd = &cobra.Command{
+	Use:   "tournament <director> [<director>...]",
+	Short: "Play directors on the same boards and seeds, and rank them",
+	Long: `Play each director on the same boards and seeds, without opening a window,
+and rank them by win rate.
+
+Directors are named as with --director, with any options following a colon, or
+as exec:<command> to run an external program (see the external director package
+for its protocol):
+	gosweep tournament constraint random "exec:python3 my_director.py"
+
+List directors and their options with
+	gosweep directors
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if tournamentConfig.Lives == 0 {
+			return fmt.Errorf("--lives must be at least 1")
+		}
+
+		for _, name := range args {
+			if _, err := newDirector(name, nil); err != nil {
+				return err
+			}
+
+			name := name
+			tournamentConfig.Entrants = append(tournamentConfig.Entrants, tournament.Entrant{
+				Name: name,
+				NewDirector: func() (game.Director, error) {
+					return newDirector(name, nil)
+				},
+			})
+		}
+
+		for _, spec := range tournamentBoards {
+			board, err := tournament.ParseBoard(spec)
+			if err != nil {
+				return err
+			}
+			tournamentConfig.Boards = append(tournamentConfig.Boards, board)
+		}
+
+		var write func(*tournament.Results) error
+		out := os.Stdout
+		if tournamentOutput != "" {
+			file, err := os.Create(tournamentOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		switch tournamentFormat {
+		case "table":
+			write = func(results *tournament.Results) error { return results.WriteTable(out) }
+		case "csv":
+			write = func(results *tournament.Results) error { return results.WriteCSV(out) }
+		case "json":
+			write = func(results *tournament.Results) error { return results.WriteJSON(out) }
+		default:
+			return fmt.Errorf("invalid format %q (expected table, csv or json)", tournamentFormat)
+		}
+
+		results, err := tournament.Run(tournamentConfig)
+		if err != nil {
+			return err
+		}
+		return write(results)
+	},
+}
+
+func init() {
+	tournamentCmd.Flags().StringSliceVar(&tournamentBoards, "board", []string{"9x9:10", "16x16:40", "30x16:99"}, "Boards to play, as WIDTHxHEIGHT:MINES or a preset (e.g. expert; may be repeated)")
+	tournamentCmd.Flags().IntVar(&tournamentConfig.NumSeeds, "games", tournamentConfig.NumSeeds, "Number of seeds to play each board with")
+	tournamentCmd.Flags().Int64Var(&tournamentConfig.FirstSeed, "seed", tournamentConfig.FirstSeed, "First seed to play each board with (seeds are consecutive)")
+	tournamentCmd.Flags().Var(newGameModeValue(game.Win7, &tournamentConfig.Mode), "mode", "Game mode, controlling behaviour of first click (win7, winxp, opening or classic)")
+	tournamentCmd.Flags().UintVar(&tournamentConfig.Lives, "lives", tournamentConfig.Lives, "Number of mines which may be revealed before losing (at least 1)")
+	tournamentCmd.Flags().IntVar(&tournamentConfig.Parallelism, "parallel", tournamentConfig.Parallelism, "Number of games to play at once")
+	tournamentCmd.Flags().StringVar(&tournamentFormat, "format", "table", "Format of the results (table, csv or json)")
+	tournamentCmd.Flags().StringVarP(&tournamentOutput, "output", "o", "", "Path to write the results to (defaults to stdout)")
+
+	rootCmd.AddCommand(tournamentCmd)
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/they4kman/gosweep/game"
 	"github.com/they4kman/gosweep/util/collections"
 	"math"
-	"reflect"
+	"sort"
 	"strings"
 	"sync"
 )
@@ -15,54 +15,93 @@ import (
 type Director struct {
 	game.BaseDirector
 
-	board *game.Board
+	// Whether to act on all of a revealed number's neighbors at once, by
+	// chording or flag chording it, rather than clicking each neighbor
+	Chord bool
+	// How to choose which cell to click, when none is known to be safe
+	Guess GuessStrategy
+	// Whether to check the observations are consistent with each other after
+	// each change, panicking if not (for debugging). They're checked against
+	// the true board by tests, through Constraints.
+	SelfCheck bool
+
+	view game.BoardView
 
 	act chan chan<- game.CellAction
 
-	observations       collections.Set[*Observation]
-	observationsByCell map[*game.Cell]collections.Set[*Observation]
-	observationsLock   *sync.Mutex
+	store            *observationStore
+	observationsLock *sync.Mutex
+
+	// Exact observations added or changed since they were last simplified
+	queuedObservations []*Observation
 }
 
 type Observation struct {
-	origin   *game.Cell
-	numMines int
-	cells    collections.Set[*game.Cell]
+	// Observations are ordered by ID (i.e. the order they were added), so
+	// they may be processed in a consistent order
+	id uint64
+
+	// Revealed cell the observation was made from (zero if it was inferred
+	// from other observations)
+	origin game.CellView
+	// Bounds of the number of mines among the cells. These are equal for
+	// observations made from revealed cells, but inferred observations may
+	// only know a range (e.g. "at least 1, and at most 2, of these 3").
+	minMines, maxMines int
+	cells              collections.Set[game.CellView]
+	// Hash of the cells (see cellsHash), kept up to date as cells are removed,
+	// to tell quickly whether observations may be of the same cells
+	hash uint64
+
+	// Whether the observation is waiting to be simplified (see
+	// Director.queuedObservations)
+	isQueued bool
+	// Last observation it was gathered as intersecting while simplifying, so
+	// it's gathered only once
+	intersectingMark uint64
 }
 
 func (observation Observation) String() string {
 	var cellsRepr strings.Builder
-	lastIdx := len(observation.cells) - 1
-	i := 0
-	for cell := range observation.cells {
-		cellsRepr.WriteString(fmt.Sprintf("(%d, %d)", cell.X(), cell.Y()))
-
-		if i != lastIdx {
+	for i, cell := range sortedCells(observation.cells) {
+		if i > 0 {
 			cellsRepr.WriteString(", ")
 		}
-		i++
+		cellsRepr.WriteString(fmt.Sprintf("(%d, %d)", cell.X(), cell.Y()))
 	}
 
 	var originRepr string
-	if observation.origin == nil {
+	if observation.origin == (game.CellView{}) {
 		originRepr = "?"
 	} else {
 		originRepr = fmt.Sprintf("(%d, %d)", observation.origin.X(), observation.origin.Y())
 	}
 
-	return fmt.Sprintf("Obs[%8s, %d ε %s]", originRepr, observation.numMines, cellsRepr.String())
+	minesRepr := fmt.Sprintf("%d", observation.minMines)
+	if !observation.IsExact() {
+		minesRepr = fmt.Sprintf("%d-%d", observation.minMines, observation.maxMines)
+	}
+
+	return fmt.Sprintf("Obs[%8s, %s ε %s]", originRepr, minesRepr, cellsRepr.String())
+}
+
+// IsExact returns whether the exact number of mines among the cells is known
+func (observation Observation) IsExact() bool {
+	return observation.minMines == observation.maxMines
 }
 
+// MineProbability returns the chance each of the cells is a mine, which is
+// only known for exact observations
 func (observation Observation) MineProbability() float32 {
-	return float32(observation.numMines) / float32(len(observation.cells))
+	return float32(observation.minMines) / float32(len(observation.cells))
 }
 
-func (director *Director) Init(board *game.Board) {
-	director.board = board
+func (director *Director) Init(view game.BoardView) {
+	director.view = view
 	director.act = make(chan chan<- game.CellAction)
 
-	director.observations = make(collections.Set[*Observation])
-	director.observationsByCell = make(map[*game.Cell]collections.Set[*Observation])
+	director.store = newObservationStore()
+	director.queuedObservations = nil
 
 	if director.observationsLock == nil {
 		director.observationsLock = &sync.Mutex{}
@@ -106,55 +145,75 @@ func (director *Director) Init(board *game.Board) {
 
 func (director *Director) actRandom(actions chan<- game.CellAction) {
 	randomDirector := &random.Director{}
-	randomDirector.Init(director.board)
-	randomDirector.Act(actions)
-	randomDirector.End()
+	for _, cellAction := range randomDirector.Next(director.view) {
+		actions <- cellAction
+	}
+	close(actions)
 }
 
 func (director *Director) actLowestProbability(actions chan<- game.CellAction) {
 	lowestProbability := float32(math.Inf(1))
-	numLowestProbabilityCells := 0
 
-	cellProbabilities := make(map[*game.Cell]float32)
-	for observation := range director.observations {
+	cellProbabilities := make(map[game.CellView]float32)
+	for observation := range director.store.observations {
+		if !observation.IsExact() {
+			continue
+		}
 		probability := observation.MineProbability()
 
 		for cell := range observation.cells {
 			if probability < lowestProbability {
 				lowestProbability = probability
-				numLowestProbabilityCells = 0
 			}
 
 			pastProbability, hasPastProbability := cellProbabilities[cell]
 			if !hasPastProbability || probability < pastProbability {
 				cellProbabilities[cell] = probability
 			}
+		}
+	}
 
-			if probability <= lowestProbability && probability != pastProbability {
-				numLowestProbabilityCells++
-			}
+	// Cells no observation includes are compared, too, in case they're safer
+	interiorCells, interiorProbability := director.interiorCells(cellProbabilities)
+
+	lowestProbabilityCells := make([]game.CellView, 0)
+	for cell, probability := range cellProbabilities {
+		if probability <= lowestProbability && probability <= interiorProbability {
+			lowestProbabilityCells = append(lowestProbabilityCells, cell)
 		}
 	}
+	if len(interiorCells) > 0 && interiorProbability <= lowestProbability {
+		lowestProbabilityCells = append(lowestProbabilityCells, interiorCells...)
+	}
 
-	if len(cellProbabilities) > 0 {
-		lowestProbabilityCells := make([]*game.Cell, numLowestProbabilityCells)
-		i := 0
-		for cell, probability := range cellProbabilities {
-			if probability <= lowestProbability {
-				lowestProbabilityCells[i] = cell
-				i++
-
-				director.board.AddAnnotation(game.Annotation{
-					Type: game.AnnotateHighlightYellow,
-					Cell: cell,
-				})
-			}
+	if len(lowestProbabilityCells) > 0 {
+		for _, cell := range lowestProbabilityCells {
+			director.view.AddAnnotation(cell.Annotate(game.AnnotateHighlightYellow))
 		}
 
-		director.board.Rand().Shuffle(len(lowestProbabilityCells), func(i, j int) {
+		// Order cells consistently before shuffling, so games are reproducible
+		// from their seed, regardless of map iteration order
+		sort.Slice(lowestProbabilityCells, func(i, j int) bool {
+			a, b := lowestProbabilityCells[i], lowestProbabilityCells[j]
+			return a.Y() < b.Y() || (a.Y() == b.Y() && a.X() < b.X())
+		})
+
+		director.view.Rand().Shuffle(len(lowestProbabilityCells), func(i, j int) {
 			lowestProbabilityCells[i], lowestProbabilityCells[j] = lowestProbabilityCells[j], lowestProbabilityCells[i]
 		})
 
+		if director.Guess == GuessProgress && len(lowestProbabilityCells) > 1 {
+			progress := make(map[game.CellView]float32, len(lowestProbabilityCells))
+			for _, cell := range lowestProbabilityCells {
+				progress[cell] = guessProgress(cell, cellProbabilities, interiorProbability)
+			}
+
+			// Cells progressing equally are left in their shuffled order
+			sort.SliceStable(lowestProbabilityCells, func(i, j int) bool {
+				return progress[lowestProbabilityCells[i]] > progress[lowestProbabilityCells[j]]
+			})
+		}
+
 		actions <- lowestProbabilityCells[0].Click()
 	}
 
@@ -162,19 +221,19 @@ func (director *Director) actLowestProbability(actions chan<- game.CellAction) {
 }
 
 func (director *Director) actEndGame(actions chan<- game.CellAction) {
-	if director.board.NumMinesRemaining() == 1 {
+	if director.view.NumMinesRemaining() == 1 {
 		director.observationsLock.Lock()
 		defer director.observationsLock.Unlock()
 
-		var sharedCells collections.Set[*game.Cell] = nil
+		var sharedCells collections.Set[game.CellView] = nil
 
-		for observation := range director.observations {
-			if observation.numMines != 1 {
+		for observation := range director.store.observations {
+			if !observation.IsExact() || observation.minMines != 1 {
 				continue
 			}
 
 			if sharedCells == nil {
-				sharedCells = make(collections.Set[*game.Cell])
+				sharedCells = make(collections.Set[game.CellView])
 				for cell := range observation.cells {
 					sharedCells.Add(cell)
 				}
@@ -201,26 +260,50 @@ func (director *Director) actDeliberate(actions chan<- game.CellAction) {
 	director.observationsLock.Lock()
 	defer director.observationsLock.Unlock()
 
+	// Observations acted on, which are done with once the step's actions
+	// are performed
+	var acted []*Observation
+
 	wg := sync.WaitGroup{}
 	findDeliberateActions := func(observations <-chan *Observation) {
 		defer wg.Done()
 
+		// Cells flagged this step. Right clicks toggle flags, so each cell must
+		// be flagged by only one action (whether a right click or flag chord).
+		flagged := make(collections.Set[game.CellView])
+
 		for observation := range observations {
-			if observation.numMines == len(observation.cells) {
-				for cell := range observation.cells {
-					actions <- cell.RightClick()
+			// Observations made from a revealed number hold all its unrevealed,
+			// unflagged neighbors, so they may be acted on by chording it
+			canChord := director.Chord && observation.origin != (game.CellView{}) && len(observation.cells) > 0
+
+			if observation.minMines == len(observation.cells) {
+				if canChord && len(flagged.Intersection(observation.cells)) == 0 {
+					actions <- observation.origin.FlagChord()
+					for cell := range observation.cells {
+						flagged.Add(cell)
+					}
+				} else {
+					for cell := range observation.cells {
+						if !flagged.Contains(cell) {
+							flagged.Add(cell)
+							actions <- cell.RightClick()
+						}
+					}
 				}
 
-				observation.cells = nil
-				observation.numMines = 0
+				acted = append(acted, observation)
 
-			} else if observation.numMines == 0 {
-				for cell := range observation.cells {
-					actions <- cell.Click()
+			} else if observation.maxMines == 0 {
+				if canChord {
+					actions <- observation.origin.MiddleClick()
+				} else {
+					for cell := range observation.cells {
+						actions <- cell.Click()
+					}
 				}
 
-				observation.cells = nil
-				observation.numMines = 0
+				acted = append(acted, observation)
 			}
 		}
 	}
@@ -231,12 +314,15 @@ func (director *Director) actDeliberate(actions chan<- game.CellAction) {
 		go findDeliberateActions(observations)
 	}
 
-	for observation := range director.observations {
+	for observation := range director.store.observations {
 		observations <- observation
 	}
 	close(observations)
 
 	wg.Wait()
+	for _, observation := range acted {
+		director.store.remove(observation)
+	}
 	close(actions)
 }
 
@@ -246,164 +332,217 @@ func (director *Director) Act(actions chan<- game.CellAction) {
 	}
 }
 
-func (director *Director) CellChanges(changes <-chan *game.Cell) {
+func (director *Director) CellChanges(changes <-chan game.CellView) {
 	logrus.Debug("Received new cell changes")
 
 	for cell := range changes {
-		if cell.IsRevealed() {
+		// Exploded mines are revealed, but only tell us they're mines
+		if cell.IsRevealed() && !cell.IsExploded() {
 			director.cellRevealed(cell)
 		}
 
-		director.observationsLock.Lock()
-		if cellObservations, ok := director.observationsByCell[cell]; ok {
-			for observation := range cellObservations {
-				if observation.numMines == 0 || len(observation.cells) == 0 {
-					delete(cellObservations, observation)
-				} else if cell.IsFlagged() {
-					director.removeObservationCell(observation, cell)
-					observation.numMines--
-				} else if cell.IsRevealed() {
-					director.removeObservationCell(observation, cell)
+		// Flagged cells (and exploded mines) are taken to be mines
+		if cell.IsRevealed() || cell.IsFlagged() {
+			director.observationsLock.Lock()
+			for observation := range director.store.byCell[cell] {
+				if remaining := director.store.removeCell(observation, cell, cell.IsFlagged()); remaining != nil {
+					director.queueObservation(remaining)
 				}
 			}
+			director.observationsLock.Unlock()
 		}
-		director.observationsLock.Unlock()
 	}
 
 	// Simplify/split observations
-	director.clearInferredObservations()
 	director.simplifyObservations()
-}
 
-func (director *Director) clearInferredObservations() {
-	for observation := range director.observations {
-		if len(observation.cells) == 0 || observation.origin == nil {
-			director.removeObservation(observation)
-			continue
+	if director.SelfCheck {
+		if err := director.store.check(); err != nil {
+			var dump strings.Builder
+			director.Dump(&dump)
+			panic(fmt.Sprintf("constraint director self-check failed: %s\n%s", err, dump.String()))
 		}
 	}
 }
 
+// Constraints returns the director's observations, so they may be checked
+// against the true board (see game.CheckableDirector)
+func (director *Director) Constraints() []game.Constraint {
+	director.observationsLock.Lock()
+	defer director.observationsLock.Unlock()
+
+	constraints := make([]game.Constraint, 0, len(director.store.observations))
+	for observation := range director.store.observations {
+		constraints = append(constraints, game.Constraint{
+			Cells:    sortedCells(observation.cells),
+			MinMines: observation.minMines,
+			MaxMines: observation.maxMines,
+		})
+	}
+	return constraints
+}
+
+// queueObservation queues an exact observation to be simplified (see
+// simplifyObservations)
+func (director *Director) queueObservation(observation *Observation) {
+	if observation.IsExact() && !observation.isQueued {
+		observation.isQueued = true
+		director.queuedObservations = append(director.queuedObservations, observation)
+	}
+}
+
+// simplifyObservations infers what it can from each pair of overlapping
+// observations, for each of the queued observations (those added or changed
+// since last simplified). Exact observations inferred are queued in turn, so
+// deductions propagate (e.g. through 1-2-1 and 1-2-2-1 patterns). Inferred
+// observations are kept, as changes to their cells are tracked as with any
+// other.
 func (director *Director) simplifyObservations() {
 	logrus.Debug("Simplifying observations")
 
-	for observation := range director.observations {
-		if len(observation.cells) == 0 {
-			director.removeObservation(observation)
+	sort.Slice(director.queuedObservations, func(i, j int) bool {
+		return director.queuedObservations[i].id < director.queuedObservations[j].id
+	})
+
+	var intersectingObservations []*Observation
+	for i := 0; i < len(director.queuedObservations); i++ {
+		observation := director.queuedObservations[i]
+		observation.isQueued = false
+
+		if !director.store.observations.Contains(observation) {
+			// Removed (or merged into another) earlier in this pass
 			continue
 		}
 
-		visited := make(collections.Set[*Observation])
-
+		// Observations still queued will be paired with this one in turn
+		intersectingObservations = intersectingObservations[:0]
+		mark := observation.id + 1
 		for cell := range observation.cells {
-			for intersectingObs := range director.observationsByCell[cell] {
-				if intersectingObs == observation {
-					continue
-				}
-				if visited.Contains(intersectingObs) {
-					continue
+			for intersectingObs := range director.store.byCell[cell] {
+				if intersectingObs != observation && !intersectingObs.isQueued && intersectingObs.intersectingMark != mark {
+					intersectingObs.intersectingMark = mark
+					intersectingObservations = append(intersectingObservations, intersectingObs)
 				}
-				visited.Add(intersectingObs)
-
-				sharedCells, isSubset := observation.cells.IntersectionEx(intersectingObs.cells)
+			}
+		}
+		sort.Slice(intersectingObservations, func(i, j int) bool {
+			return intersectingObservations[i].id < intersectingObservations[j].id
+		})
 
-				if isSubset {
-					splitObs := Observation{
-						numMines: intersectingObs.numMines - observation.numMines,
-						cells:    intersectingObs.cells.Difference(observation.cells),
-					}
-					director.addObservation(&splitObs)
+		for _, intersectingObs := range intersectingObservations {
+			for _, inferredObs := range inferObservations(observation, intersectingObs) {
+				if changedObs := director.store.add(inferredObs); changedObs != nil {
+					// Ranges are used alongside the observations which follow,
+					// but only exact observations are simplified in turn, as
+					// the ranges inferred from ranges quickly multiply
+					director.queueObservation(changedObs)
 
 					logrus.Debugf(
-						"Subset:     %s\n  Superset: %s\n  Split:    %s",
-						observation, intersectingObs, splitObs)
-
-				} else if observation.numMines == 1 && len(sharedCells) > 1 {
-					// Only cells in intersectingObs
-					leftOnlyCells := intersectingObs.cells.Difference(sharedCells)
-					occludedMines := intersectingObs.numMines - observation.numMines
-
-					if occludedMines == len(leftOnlyCells) {
-						occludedObs := Observation{
-							numMines: occludedMines,
-							cells:    leftOnlyCells,
-						}
-
-						director.addObservation(&occludedObs)
-
-						logrus.Debugf(
-							"Limiting:   %s\n  Limited:  %s\n  Occluded:    %s",
-							observation, intersectingObs, occludedObs)
-					}
+						"Overlap:    %s\n  With:     %s\n  Inferred: %s",
+						observation, intersectingObs, changedObs)
 				}
 			}
 		}
 	}
+
+	director.queuedObservations = director.queuedObservations[:0]
 }
 
-func (director *Director) addObservation(observation *Observation) {
-	// Don't add vacuous observations
-	if len(observation.cells) == 0 {
-		return
+// inferObservations bounds the number of mines among the cells shared by two
+// observations, and among the cells only in one or the other, returning an
+// observation for each of these whose bounds are worth keeping
+func inferObservations(a, b *Observation) []*Observation {
+	smaller, larger := a.cells, b.cells
+	if len(smaller) > len(larger) {
+		smaller, larger = larger, smaller
 	}
 
-	dupeVisited := make(collections.Set[*Observation])
-	for cell := range observation.cells {
-		var cellObservations collections.Set[*Observation]
-		var exists bool
-		if cellObservations, exists = director.observationsByCell[cell]; !exists {
-			cellObservations = make(collections.Set[*Observation])
-			director.observationsByCell[cell] = cellObservations
+	numShared := 0
+	for cell := range smaller {
+		if larger.Contains(cell) {
+			numShared++
 		}
+	}
+	if numShared == 0 {
+		return nil
+	}
+	numAOnly, numBOnly := len(a.cells)-numShared, len(b.cells)-numShared
+
+	// Each observation's mines which can't fit in its own cells are shared
+	sharedMin := max(0, a.minMines-numAOnly, b.minMines-numBOnly)
+	sharedMax := min(numShared, a.maxMines, b.maxMines)
+	if sharedMin > sharedMax {
+		// The observations contradict each other
+		return nil
+	}
 
-		for otherObs := range cellObservations {
-			if dupeVisited.Contains(otherObs) {
-				continue
-			}
-			dupeVisited.Add(otherObs)
-
-			// Don't add duplicates
-			if reflect.DeepEqual(observation.cells, otherObs.cells) {
-				return
-			}
+	// Bounds are only worth keeping if they say some of the cells must be
+	// mines, or that none of them are. Bounds saying only that there are at
+	// most some number of mines are plentiful (e.g. between any two 1s), and
+	// rarely lead anywhere the pair of observations doesn't lead directly.
+	// Cells are only gathered for bounds worth keeping, as most pairs of
+	// observations say nothing new.
+	var inferred []*Observation
+	addInferred := func(numCells, minMines, maxMines int, cells func() collections.Set[game.CellView]) {
+		minMines, maxMines = max(minMines, 0), min(maxMines, numCells)
+		if numCells > 0 && minMines <= maxMines && (minMines > 0 || maxMines == 0) {
+			inferred = append(inferred, &Observation{
+				minMines: minMines,
+				maxMines: maxMines,
+				cells:    cells(),
+			})
 		}
-
-		cellObservations.Add(observation)
 	}
 
-	director.observations.Add(observation)
+	addInferred(numShared, sharedMin, sharedMax, func() collections.Set[game.CellView] {
+		return a.cells.Intersection(b.cells)
+	})
+	addInferred(numAOnly, a.minMines-sharedMax, a.maxMines-sharedMin, func() collections.Set[game.CellView] {
+		return a.cells.Difference(b.cells)
+	})
+	addInferred(numBOnly, b.minMines-sharedMax, b.maxMines-sharedMin, func() collections.Set[game.CellView] {
+		return b.cells.Difference(a.cells)
+	})
+	return inferred
 }
 
-func (director *Director) removeObservation(observation *Observation) {
-	delete(director.observations, observation)
-
-	for cell := range observation.cells {
-		delete(director.observationsByCell[cell], observation)
+// sortedObservations returns the observations in the order they were added
+func sortedObservations(observations collections.Set[*Observation]) []*Observation {
+	sorted := make([]*Observation, 0, len(observations))
+	for observation := range observations {
+		sorted = append(sorted, observation)
 	}
-}
 
-func (director *Director) removeObservationCell(observation *Observation, cell *game.Cell) {
-	delete(observation.cells, cell)
-	delete(director.observationsByCell[cell], observation)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].id < sorted[j].id
+	})
+	return sorted
 }
 
-func (director *Director) cellRevealed(cell *game.Cell) {
-	observation := Observation{
-		origin:   cell,
-		numMines: int(cell.NumMines()),
-		cells:    make(collections.Set[*game.Cell]),
-	}
+func (director *Director) cellRevealed(cell game.CellView) {
+	numMines := int(cell.NumMines())
+	cells := make(collections.Set[game.CellView])
 
-	for neighbor := range cell.Neighbors() {
-		if !neighbor.IsRevealed() {
+	var neighbors [8]game.CellView
+	for _, neighbor := range cell.AppendNeighbors(neighbors[:0]) {
+		if neighbor.IsExploded() {
+			numMines--
+		} else if !neighbor.IsRevealed() {
 			if neighbor.IsFlagged() {
-				observation.numMines--
+				numMines--
 			} else {
-				observation.cells.Add(neighbor)
+				cells.Add(neighbor)
 			}
 		}
 	}
 
+	observation := Observation{
+		origin:   cell,
+		minMines: numMines,
+		maxMines: numMines,
+		cells:    cells,
+	}
+
 	if len(observation.cells) == 0 {
 		return
 	}
@@ -411,12 +550,14 @@ func (director *Director) cellRevealed(cell *game.Cell) {
 	director.observationsLock.Lock()
 	defer director.observationsLock.Unlock()
 
-	director.addObservation(&observation)
+	if addedObs := director.store.add(&observation); addedObs != nil {
+		director.queueObservation(addedObs)
+	}
 
 	logrus.Debugf(
 		"Found observation, from %d(%d, %d): g|%d, %d|",
 		cell.NumMines(), cell.X(), cell.Y(),
-		observation.numMines, len(observation.cells))
+		observation.minMines, len(observation.cells))
 }
 
 func (director *Director) End() {
@@ -0,0 +1,188 @@
+package constraint
+
+import (
+	"fmt"
+	"github.com/they4kman/gosweep/game"
+	"reflect"
+	"testing"
+)
+
+// TestDirectorSelfCheck plays boards with the store's self-check on, and the
+// director's observations checked against the true board after each step
+func TestDirectorSelfCheck(t *testing.T) {
+	modes := []game.GameMode{game.Classic, game.Win7, game.WinXP, game.Opening}
+	presets := []game.Preset{game.Beginner, game.Intermediate, game.Expert}
+
+	for _, mode := range modes {
+		for _, lives := range []uint{1, 3} {
+			for _, chord := range []bool{false, true} {
+				name := fmt.Sprintf("mode=%d,lives=%d,chord=%v", mode, lives, chord)
+				t.Run(name, func(t *testing.T) {
+					for seed := int64(1); seed <= 6; seed++ {
+						preset := presets[seed%int64(len(presets))]
+
+						config := game.NewGameConfig()
+						config.Width, config.Height, config.NumMines = preset.Width, preset.Height, preset.NumMines
+						config.Mode = mode
+						config.Lives = lives
+						config.Seed = seed
+						config.Director = &Director{Chord: chord, Guess: GuessProgress, SelfCheck: true}
+						config.CheckDirector = true
+
+						result, err := game.PlayHeadless(config)
+						if err != nil {
+							t.Fatalf("seed %d: %v", seed, err)
+						}
+						if result.State == game.Ongoing {
+							t.Errorf("seed %d: the director gave up after %d steps", seed, result.NumSteps)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// testBounds is an observation's bounds of mines among cells (by index, into
+// testCells)
+type testBounds struct {
+	minMines, maxMines int
+	cells              []int
+}
+
+func (bounds testBounds) observation(cells []game.CellView) *Observation {
+	observation := newTestObservation(bounds.minMines, bounds.maxMines)
+	for _, idx := range bounds.cells {
+		observation.cells.Add(cells[idx])
+	}
+	return observation
+}
+
+// boundsOf returns the observations' bounds, by their cells
+func boundsOf(observations []*Observation) map[string]string {
+	bounds := make(map[string]string, len(observations))
+	for _, observation := range observations {
+		bounds[fmt.Sprint(sortedCells(observation.cells))] = fmt.Sprintf("%d-%d", observation.minMines, observation.maxMines)
+	}
+	return bounds
+}
+
+// Each pattern is a row of revealed numbers below a row of unknown cells (A,
+// B, C, ... = 0, 1, 2, ...), each number observing the three cells above it
+func TestInferObservations(t *testing.T) {
+	const a, b, c, d, e = 0, 1, 2, 3, 4
+
+	tests := []struct {
+		name     string
+		a, b     testBounds
+		inferred []testBounds
+	}{
+		{
+			// 1-2-1, first pair: B or C holds the 1's mine, which the 2 shares,
+			// so A is safe and D is a mine
+			name: "1-2-1",
+			a:    testBounds{1, 1, []int{a, b, c}},
+			b:    testBounds{2, 2, []int{b, c, d}},
+			inferred: []testBounds{
+				{1, 1, []int{b, c}},
+				{0, 0, []int{a}},
+				{1, 1, []int{d}},
+			},
+		},
+		{
+			// 1-2-2-1, middle pair: the 2s share at least 1 mine, but say
+			// nothing of B or E alone, until paired with the 1s
+			name: "1-2-2-1",
+			a:    testBounds{2, 2, []int{b, c, d}},
+			b:    testBounds{2, 2, []int{c, d, e}},
+			inferred: []testBounds{
+				{1, 2, []int{c, d}},
+			},
+		},
+		{
+			// 2 among A-D, and 1 among D-E: D holds at most 1 mine, so at
+			// least 1 of A-C is a mine
+			name: "at least 1 of 3",
+			a:    testBounds{2, 2, []int{a, b, c, d}},
+			b:    testBounds{1, 1, []int{d, e}},
+			inferred: []testBounds{
+				{1, 2, []int{a, b, c}},
+			},
+		},
+		{
+			name: "disjoint",
+			a:    testBounds{1, 1, []int{a, b}},
+			b:    testBounds{1, 1, []int{d, e}},
+		},
+		{
+			name: "contradicting",
+			a:    testBounds{0, 0, []int{a, b}},
+			b:    testBounds{2, 2, []int{a, b}},
+		},
+	}
+
+	cells := testCells(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			expected := make([]*Observation, len(test.inferred))
+			for i, bounds := range test.inferred {
+				expected[i] = bounds.observation(cells)
+			}
+
+			inferred := inferObservations(test.a.observation(cells), test.b.observation(cells))
+			if got, want := boundsOf(inferred), boundsOf(expected); !reflect.DeepEqual(got, want) {
+				t.Errorf("inferred %v, expected %v", got, want)
+			}
+		})
+	}
+}
+
+// TestSimplifyObservationsResolvesPatterns checks the patterns are resolved
+// by simplifying alone, without guessing
+func TestSimplifyObservationsResolvesPatterns(t *testing.T) {
+	tests := []struct {
+		name    string
+		numbers []int
+		mines   []int
+	}{
+		{"1-2-1", []int{1, 2, 1}, []int{1, 3}},
+		{"1-2-2-1", []int{1, 2, 2, 1}, []int{2, 3}},
+	}
+
+	cells := testCells(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			director := &Director{store: newObservationStore()}
+			for i, number := range test.numbers {
+				observation := testBounds{number, number, []int{i, i + 1, i + 2}}.observation(cells)
+				director.queueObservation(director.store.add(observation))
+			}
+			director.simplifyObservations()
+
+			isMine := make(map[game.CellView]bool)
+			for _, idx := range test.mines {
+				isMine[cells[idx]] = true
+			}
+
+			known := make(map[game.CellView]bool)
+			for observation := range director.store.observations {
+				for cell := range observation.cells {
+					switch {
+					case observation.maxMines == 0 && isMine[cell]:
+						t.Errorf("mine %s was inferred safe", cell)
+					case observation.minMines == len(observation.cells) && !isMine[cell]:
+						t.Errorf("safe %s was inferred a mine", cell)
+					case observation.maxMines == 0 || observation.minMines == len(observation.cells):
+						known[cell] = true
+					}
+				}
+			}
+
+			for _, cell := range cells[:len(test.numbers)+2] {
+				if !known[cell] {
+					t.Errorf("%s is still unknown", cell)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package constraint
+
+import (
+	"github.com/they4kman/gosweep/game"
+)
+
+// GuessStrategy decides which cell to click when no cell is known to be safe,
+// among those least likely to be mines
+type GuessStrategy int
+
+const (
+	// Prefer cells likeliest to lead somewhere: those whose numbers are likely
+	// zeros (opening up their neighbors), such as corners and edges, over
+	// those whose numbers can tell nothing new (e.g. either side of a 50/50)
+	GuessProgress GuessStrategy = iota
+	// Choose any at random
+	GuessRandom
+)
+
+// GuessStrategies names the guess strategies, as they may be chosen from the
+// command line
+var GuessStrategies = map[string]GuessStrategy{
+	"progress": GuessProgress,
+	"random":   GuessRandom,
+}
+
+// guessProgress scores how much a guess of the cell is expected to progress
+// the game, if it's safe: the chance its number is zero, which reveals its
+// neighbors in turn. Cells with no unknown neighbors can't lead anywhere, and
+// score lowest.
+//
+// The chance each neighbor is a mine is taken from cellProbabilities, or is
+// otherwise interiorProbability (for cells no observation includes).
+func guessProgress(
+	cell game.CellView,
+	cellProbabilities map[game.CellView]float32,
+	interiorProbability float32,
+) float32 {
+	numUnknown := 0
+	zeroChance := float32(1)
+
+	var neighbors [8]game.CellView
+	for _, neighbor := range cell.AppendNeighbors(neighbors[:0]) {
+		if neighbor.IsRevealed() || neighbor.IsFlagged() {
+			continue
+		}
+		numUnknown++
+
+		probability, isObserved := cellProbabilities[neighbor]
+		if !isObserved {
+			probability = interiorProbability
+		}
+		zeroChance *= 1 - probability
+	}
+
+	if numUnknown == 0 {
+		return -1
+	}
+	return zeroChance
+}
+
+// interiorCells returns the unknown cells no observation includes, along with
+// the chance each is a mine. The mines expected among the cells observations
+// include (by cellProbabilities) are set aside, and the rest of the mines
+// remaining are spread evenly over the interior. If there's no interior, the
+// chance is 1, as none of it is safe.
+func (director *Director) interiorCells(cellProbabilities map[game.CellView]float32) ([]game.CellView, float32) {
+	var interior []game.CellView
+	for _, cell := range director.view.Cells() {
+		if cell.IsVoid() || cell.IsRevealed() || cell.IsFlagged() {
+			continue
+		}
+		if len(director.store.byCell[cell]) == 0 {
+			interior = append(interior, cell)
+		}
+	}
+
+	if len(interior) == 0 {
+		return nil, 1
+	}
+
+	frontierMines := float32(0)
+	for _, probability := range cellProbabilities {
+		frontierMines += probability
+	}
+
+	interiorMines := float32(director.view.NumMinesRemaining()) - frontierMines
+	return interior, min(max(interiorMines/float32(len(interior)), 0), 1)
+}
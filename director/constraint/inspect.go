@@ -0,0 +1,86 @@
+package constraint
+
+import (
+	"fmt"
+	"github.com/they4kman/gosweep/game"
+	"github.com/they4kman/gosweep/util/collections"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Dump writes all the director's observations, in the order they were added,
+// followed by the observations of each cell (by their IDs)
+func (director *Director) Dump(out io.Writer) {
+	director.observationsLock.Lock()
+	defer director.observationsLock.Unlock()
+
+	fmt.Fprintf(out, "Observations (%d):\n", len(director.store.observations))
+	for _, observation := range sortedObservations(director.store.observations) {
+		fmt.Fprintf(out, "  #%-5d %s\n", observation.id, observation)
+	}
+
+	cells := make(collections.Set[game.CellView], len(director.store.byCell))
+	for cell, cellObservations := range director.store.byCell {
+		if len(cellObservations) > 0 {
+			cells.Add(cell)
+		}
+	}
+
+	fmt.Fprintf(out, "Observations by cell (%d):\n", len(cells))
+	for _, cell := range sortedCells(cells) {
+		var ids strings.Builder
+		for i, observation := range sortedObservations(director.store.byCell[cell]) {
+			if i > 0 {
+				ids.WriteString(", ")
+			}
+			fmt.Fprintf(&ids, "#%d", observation.id)
+		}
+		fmt.Fprintf(out, "  %-10s %s\n", fmt.Sprintf("(%d, %d)", cell.X(), cell.Y()), ids.String())
+	}
+}
+
+// Inspect returns the cells of the observations including the cell, or made
+// from it, describing each observation
+func (director *Director) Inspect(cell game.CellView) ([]game.CellView, []string) {
+	director.observationsLock.Lock()
+	defer director.observationsLock.Unlock()
+
+	observations := make(collections.Set[*Observation])
+	for observation := range director.store.byCell[cell] {
+		observations.Add(observation)
+	}
+	for observation := range director.store.observations {
+		if observation.origin == cell {
+			observations.Add(observation)
+		}
+	}
+
+	related := make(collections.Set[game.CellView])
+	lines := make([]string, 0, len(observations))
+	for _, observation := range sortedObservations(observations) {
+		for relatedCell := range observation.cells {
+			related.Add(relatedCell)
+		}
+		lines = append(lines, fmt.Sprintf("#%d %s", observation.id, observation))
+	}
+
+	if len(lines) == 0 {
+		lines = append(lines, "No observations")
+	}
+	return sortedCells(related), lines
+}
+
+// sortedCells returns the cells ordered by row, then column
+func sortedCells(cells collections.Set[game.CellView]) []game.CellView {
+	sorted := make([]game.CellView, 0, len(cells))
+	for cell := range cells {
+		sorted = append(sorted, cell)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		return a.Y() < b.Y() || (a.Y() == b.Y() && a.X() < b.X())
+	})
+	return sorted
+}
@@ -0,0 +1,247 @@
+package constraint
+
+import (
+	"fmt"
+	"github.com/they4kman/gosweep/game"
+	"github.com/they4kman/gosweep/util/collections"
+)
+
+// observationStore holds the director's observations, indexed by their cells.
+// Observations are only changed through the store, which keeps them
+// consistent:
+//   - each observation has at least one cell, and 0 <= minMines <= maxMines <=
+//     its number of cells
+//   - byCell holds each observation under each of its cells, and no others
+//   - byHash holds each observation under the hash of its cells, and no two
+//     observations have the same cells
+type observationStore struct {
+	observations collections.Set[*Observation]
+	byCell       map[game.CellView]collections.Set[*Observation]
+	byHash       map[uint64][]*Observation
+
+	// ID to assign the next added observation
+	nextID uint64
+}
+
+func newObservationStore() *observationStore {
+	return &observationStore{
+		observations: make(collections.Set[*Observation]),
+		byCell:       make(map[game.CellView]collections.Set[*Observation]),
+		byHash:       make(map[uint64][]*Observation),
+	}
+}
+
+// add adds the observation, or tightens the bounds of an existing observation
+// of the same cells. The observation added or tightened is returned, or nil if
+// nothing was learned.
+func (store *observationStore) add(observation *Observation) *Observation {
+	// Don't add vacuous observations
+	if len(observation.cells) == 0 {
+		return nil
+	}
+
+	observation.hash = cellsHash(observation.cells)
+	if existing := store.sameAs(observation); existing != nil {
+		if !existing.tighten(observation) {
+			return nil
+		}
+		return existing
+	}
+
+	observation.id = store.nextID
+	store.nextID++
+
+	store.observations.Add(observation)
+	for cell := range observation.cells {
+		cellObservations, exists := store.byCell[cell]
+		if !exists {
+			cellObservations = make(collections.Set[*Observation])
+			store.byCell[cell] = cellObservations
+		}
+		cellObservations.Add(observation)
+	}
+	store.byHash[observation.hash] = append(store.byHash[observation.hash], observation)
+
+	return observation
+}
+
+func (store *observationStore) remove(observation *Observation) {
+	delete(store.observations, observation)
+	for cell := range observation.cells {
+		delete(store.byCell[cell], observation)
+	}
+	store.unhash(observation)
+}
+
+// removeCell removes the cell from the observation, which is a mine if
+// isMine, and is otherwise safe. The observation is removed if it's left
+// without cells, or merged with any other of the cells it's left with. The
+// observation left is returned, or nil if it was removed.
+func (store *observationStore) removeCell(observation *Observation, cell game.CellView, isMine bool) *Observation {
+	store.unhash(observation)
+	delete(observation.cells, cell)
+	delete(store.byCell[cell], observation)
+	observation.hash ^= cellHash(cell)
+
+	if isMine {
+		observation.minMines--
+		observation.maxMines--
+	}
+	// Bounds are kept within the cells left. A mine where none were thought
+	// to be (e.g. a cell flagged by mistake) leaves the rest safe.
+	observation.minMines = min(max(observation.minMines, 0), len(observation.cells))
+	observation.maxMines = min(max(observation.maxMines, observation.minMines), len(observation.cells))
+
+	if len(observation.cells) == 0 {
+		store.remove(observation)
+		return nil
+	}
+
+	// Observations left with the same cells are merged into the one added
+	// first
+	if existing := store.sameAs(observation); existing != nil {
+		if existing.id < observation.id {
+			store.remove(observation)
+			existing.tighten(observation)
+			return existing
+		}
+		store.remove(existing)
+		observation.tighten(existing)
+	}
+
+	store.byHash[observation.hash] = append(store.byHash[observation.hash], observation)
+	return observation
+}
+
+// sameAs returns the observation stored with the same cells as the
+// observation (whose hash must be up to date), if any
+func (store *observationStore) sameAs(observation *Observation) *Observation {
+	for _, other := range store.byHash[observation.hash] {
+		if other != observation && sameCells(observation.cells, other.cells) {
+			return other
+		}
+	}
+	return nil
+}
+
+// unhash removes the observation from byHash, under its current hash
+func (store *observationStore) unhash(observation *Observation) {
+	hashed := store.byHash[observation.hash]
+	for i, other := range hashed {
+		if other == observation {
+			hashed[i] = hashed[len(hashed)-1]
+			hashed = hashed[:len(hashed)-1]
+			break
+		}
+	}
+
+	if len(hashed) == 0 {
+		delete(store.byHash, observation.hash)
+	} else {
+		store.byHash[observation.hash] = hashed
+	}
+}
+
+// tighten narrows the observation's bounds to those of another observation of
+// the same cells, returning whether they changed. Bounds contradicting the
+// observation's are ignored. An origin is kept, if the observation had none.
+func (observation *Observation) tighten(other *Observation) bool {
+	if observation.origin == (game.CellView{}) {
+		observation.origin = other.origin
+	}
+
+	minMines := max(observation.minMines, other.minMines)
+	maxMines := min(observation.maxMines, other.maxMines)
+	if (minMines == observation.minMines && maxMines == observation.maxMines) || minMines > maxMines {
+		return false
+	}
+
+	observation.minMines, observation.maxMines = minMines, maxMines
+	return true
+}
+
+// check returns an error describing the first inconsistency found in the
+// store, if any
+func (store *observationStore) check() error {
+	numHashed := 0
+	for _, hashed := range store.byHash {
+		numHashed += len(hashed)
+	}
+	if numHashed != len(store.observations) {
+		return fmt.Errorf("%d observations are indexed by hash, but there are %d", numHashed, len(store.observations))
+	}
+
+	for observation := range store.observations {
+		if len(observation.cells) == 0 {
+			return fmt.Errorf("#%d %s has no cells", observation.id, observation)
+		}
+		if observation.minMines < 0 || observation.minMines > observation.maxMines || observation.maxMines > len(observation.cells) {
+			return fmt.Errorf("#%d %s has invalid bounds", observation.id, observation)
+		}
+		if observation.hash != cellsHash(observation.cells) {
+			return fmt.Errorf("#%d %s has a stale hash", observation.id, observation)
+		}
+		if existing := store.sameAs(observation); existing != nil {
+			return fmt.Errorf("#%d %s duplicates #%d %s", observation.id, observation, existing.id, existing)
+		}
+
+		isHashed := false
+		for _, other := range store.byHash[observation.hash] {
+			isHashed = isHashed || other == observation
+		}
+		if !isHashed {
+			return fmt.Errorf("#%d %s is not indexed by its hash", observation.id, observation)
+		}
+
+		for cell := range observation.cells {
+			if !store.byCell[cell].Contains(observation) {
+				return fmt.Errorf("#%d %s is not indexed by its cell (%d, %d)", observation.id, observation, cell.X(), cell.Y())
+			}
+			if cell.IsRevealed() || cell.IsFlagged() {
+				return fmt.Errorf("#%d %s holds known cell (%d, %d)", observation.id, observation, cell.X(), cell.Y())
+			}
+		}
+	}
+
+	for cell, cellObservations := range store.byCell {
+		for observation := range cellObservations {
+			if !store.observations.Contains(observation) || !observation.cells.Contains(cell) {
+				return fmt.Errorf("#%d %s is indexed by cell (%d, %d), which it doesn't hold", observation.id, observation, cell.X(), cell.Y())
+			}
+		}
+	}
+
+	return nil
+}
+
+// cellsHash returns a hash of the set of cells, which is the same for equal
+// sets, and may be updated as cells are added or removed by XORing in
+// cellHash(cell)
+func cellsHash(cells collections.Set[game.CellView]) uint64 {
+	hash := uint64(0)
+	for cell := range cells {
+		hash ^= cellHash(cell)
+	}
+	return hash
+}
+
+func cellHash(cell game.CellView) uint64 {
+	// SplitMix64's finalizer, spreading the position's bits over the hash
+	z := uint64(cell.Y())<<32 | uint64(cell.X())
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// sameCells returns whether the sets hold the same cells
+func sameCells(a, b collections.Set[game.CellView]) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for cell := range a {
+		if !b.Contains(cell) {
+			return false
+		}
+	}
+	return true
+}
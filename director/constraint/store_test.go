@@ -0,0 +1,231 @@
+package constraint
+
+import (
+	"github.com/they4kman/gosweep/game"
+	"github.com/they4kman/gosweep/util/collections"
+	"testing"
+)
+
+// viewDirector only keeps the view of the board it's given, so tests have
+// cells to make observations of
+type viewDirector struct {
+	game.BaseDirector
+	view game.BoardView
+}
+
+func (director *viewDirector) Init(view game.BoardView) {
+	director.view = view
+}
+
+func (director *viewDirector) Act(actions chan<- game.CellAction) {
+	close(actions)
+}
+
+// testCells returns the cells of a 9x9 board, by index
+func testCells(t *testing.T) []game.CellView {
+	director := &viewDirector{}
+
+	config := game.NewGameConfig()
+	config.Width, config.Height, config.NumMines = 9, 9, 10
+	config.Director = director
+	if _, err := game.PlayHeadless(config); err != nil {
+		t.Fatal(err)
+	}
+	return director.view.Cells()
+}
+
+func newTestObservation(minMines, maxMines int, cells ...game.CellView) *Observation {
+	return &Observation{
+		minMines: minMines,
+		maxMines: maxMines,
+		cells:    cellSet(cells...),
+	}
+}
+
+func cellSet(cells ...game.CellView) collections.Set[game.CellView] {
+	set := make(collections.Set[game.CellView])
+	for _, cell := range cells {
+		set.Add(cell)
+	}
+	return set
+}
+
+func checkStore(t *testing.T, store *observationStore) {
+	t.Helper()
+	if err := store.check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreAddDedupes(t *testing.T) {
+	cells := testCells(t)
+	store := newObservationStore()
+
+	first := store.add(newTestObservation(0, 2, cells[0], cells[1], cells[2]))
+	if first == nil {
+		t.Fatal("expected the observation to be added")
+	}
+	if store.add(newTestObservation(0, 0)) != nil {
+		t.Error("expected an observation without cells not to be added")
+	}
+	checkStore(t, store)
+
+	// The same cells, in another order, tighten the existing observation
+	if tightened := store.add(newTestObservation(1, 3, cells[2], cells[0], cells[1])); tightened != first {
+		t.Fatalf("added %v, expected %v to be tightened", tightened, first)
+	}
+	if first.minMines != 1 || first.maxMines != 2 {
+		t.Errorf("tightened to %d-%d mines, expected 1-2", first.minMines, first.maxMines)
+	}
+	if store.add(newTestObservation(0, 3, cells[0], cells[1], cells[2])) != nil {
+		t.Error("expected looser bounds to teach nothing")
+	}
+	if store.add(newTestObservation(3, 3, cells[0], cells[1], cells[2])) != nil || first.minMines != 1 || first.maxMines != 2 {
+		t.Error("expected contradicting bounds to be ignored")
+	}
+
+	if len(store.observations) != 1 {
+		t.Errorf("%d observations stored, expected 1", len(store.observations))
+	}
+	checkStore(t, store)
+}
+
+func TestStoreRemoveCell(t *testing.T) {
+	cells := testCells(t)
+	store := newObservationStore()
+
+	observation := store.add(newTestObservation(2, 2, cells[0], cells[1], cells[2]))
+	other := store.add(newTestObservation(1, 1, cells[1], cells[3]))
+	checkStore(t, store)
+
+	// Removing a mine lowers the bounds
+	if remaining := store.removeCell(observation, cells[0], true); remaining != observation {
+		t.Fatalf("removing a cell left %v, expected %v", remaining, observation)
+	}
+	if observation.minMines != 1 || observation.maxMines != 1 || len(observation.cells) != 2 {
+		t.Errorf("left %s, expected 1 mine among 2 cells", observation)
+	}
+	if store.byCell[cells[0]].Contains(observation) {
+		t.Error("expected the observation no longer to be indexed by the removed cell")
+	}
+	checkStore(t, store)
+
+	// A mine where none were thought to be leaves the rest safe
+	store.removeCell(other, cells[3], true)
+	if other.minMines != 0 || other.maxMines != 0 {
+		t.Errorf("left %s, expected no mines", other)
+	}
+	checkStore(t, store)
+
+	// Removing the last cell removes the observation
+	if remaining := store.removeCell(other, cells[1], false); remaining != nil {
+		t.Errorf("removing the last cell left %v, expected nil", remaining)
+	}
+	if store.observations.Contains(other) {
+		t.Error("expected the observation left without cells to be removed")
+	}
+	checkStore(t, store)
+}
+
+func TestStoreRemoveCellMerges(t *testing.T) {
+	cells := testCells(t)
+	store := newObservationStore()
+
+	older := store.add(newTestObservation(0, 2, cells[0], cells[1]))
+	newer := store.add(newTestObservation(1, 1, cells[0], cells[1], cells[2]))
+	checkStore(t, store)
+
+	// Left with the same cells as the older observation, the newer one is
+	// merged into it, tightening its bounds
+	if remaining := store.removeCell(newer, cells[2], false); remaining != older {
+		t.Fatalf("removing a cell left %v, expected it merged into %v", remaining, older)
+	}
+	if store.observations.Contains(newer) || len(store.observations) != 1 {
+		t.Error("expected only the older observation to be left")
+	}
+	if older.minMines != 1 || older.maxMines != 1 {
+		t.Errorf("merged into %s, expected 1 mine", older)
+	}
+	checkStore(t, store)
+
+	// The older observation is kept, even when it's the one changed
+	newer = store.add(newTestObservation(1, 1, cells[1]))
+	if remaining := store.removeCell(older, cells[0], false); remaining != older {
+		t.Fatalf("removing a cell left %v, expected %v", remaining, older)
+	}
+	if store.observations.Contains(newer) || len(store.observations) != 1 {
+		t.Error("expected the newer observation to be merged away")
+	}
+	checkStore(t, store)
+}
+
+func TestStoreRemove(t *testing.T) {
+	cells := testCells(t)
+	store := newObservationStore()
+
+	observation := store.add(newTestObservation(1, 1, cells[0], cells[1]))
+	store.remove(observation)
+	checkStore(t, store)
+
+	if len(store.observations) != 0 || len(store.byHash) != 0 {
+		t.Error("expected the store to be empty")
+	}
+	for _, cell := range cells[:2] {
+		if len(store.byCell[cell]) != 0 {
+			t.Errorf("expected %s not to index any observations", cell)
+		}
+	}
+
+	// The same cells may be observed again
+	if store.add(newTestObservation(1, 1, cells[0], cells[1])) == nil {
+		t.Error("expected the observation to be added again")
+	}
+	checkStore(t, store)
+}
+
+func TestStoreCheck(t *testing.T) {
+	cells := testCells(t)
+
+	tests := []struct {
+		name    string
+		corrupt func(store *observationStore, observation *Observation)
+	}{
+		{"invalid bounds", func(store *observationStore, observation *Observation) {
+			observation.maxMines = 3
+		}},
+		{"stale hash", func(store *observationStore, observation *Observation) {
+			delete(observation.cells, cells[0])
+		}},
+		{"unindexed cell", func(store *observationStore, observation *Observation) {
+			delete(store.byCell[cells[0]], observation)
+		}},
+		{"stale cell index", func(store *observationStore, observation *Observation) {
+			store.byCell[cells[5]] = collections.Set[*Observation]{observation: {}}
+		}},
+		{"unhashed", func(store *observationStore, observation *Observation) {
+			store.unhash(observation)
+		}},
+		{"duplicate", func(store *observationStore, observation *Observation) {
+			duplicate := newTestObservation(1, 1, cells[0], cells[1])
+			duplicate.hash = observation.hash
+			store.observations.Add(duplicate)
+			store.byHash[duplicate.hash] = append(store.byHash[duplicate.hash], duplicate)
+			for cell := range duplicate.cells {
+				store.byCell[cell].Add(duplicate)
+			}
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := newObservationStore()
+			observation := store.add(newTestObservation(1, 1, cells[0], cells[1]))
+			checkStore(t, store)
+
+			test.corrupt(store, observation)
+			if err := store.check(); err == nil {
+				t.Error("expected the inconsistency to be found")
+			}
+		})
+	}
+}
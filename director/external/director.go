@@ -0,0 +1,195 @@
+// Package external runs directors as separate programs, which may be written
+// in any language. The program is started when the game starts, and talks to
+// gosweep over its stdin and stdout.
+//
+// Each step, the program is sent the board as a player would see it:
+//
+//	board <width> <height> <mines remaining> <lives remaining>
+//	<one line of <width> characters for each of the <height> rows>
+//
+// where each character is one of:
+//
+//	#    unrevealed
+//	F    flagged
+//	?    marked with a "?"
+//	X    mine revealed at the cost of a life
+//	0-8  revealed, with that many surrounding mines
+//	-    void (not part of the board)
+//
+// The program replies with any number of actions, one per line, followed by a
+// line reading "end". Replying with no actions gives up the game.
+//
+//	click <x> <y>
+//	flag <x> <y>        (right click, toggling a flag)
+//	question <x> <y>    (toggling a "?" mark, e.g. to tag cells under consideration)
+//	chord <x> <y>       (middle click)
+//	flag-chord <x> <y>  (flagging all unrevealed neighbors of a number, if they
+//	                    must all be mines)
+//	end
+//
+// The program's stdin is closed when the game ends.
+package external
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/they4kman/gosweep/game"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type Director struct {
+	// Program and arguments to run
+	command []string
+
+	process *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  *bufio.Reader
+}
+
+// New creates a director running the command, which is split on whitespace
+// into the program and its arguments
+func New(command string) *Director {
+	return &Director{command: strings.Fields(command)}
+}
+
+func (director *Director) Next(view game.BoardView) []game.CellAction {
+	if director.process == nil {
+		if err := director.start(); err != nil {
+			logrus.Errorf("Unable to start external director %q: %v", strings.Join(director.command, " "), err)
+			return nil
+		}
+	}
+
+	if err := director.sendBoard(view); err != nil {
+		logrus.Errorf("Unable to send board to external director: %v", err)
+		return nil
+	}
+
+	actions, err := director.receiveActions(view)
+	if err != nil {
+		logrus.Errorf("Invalid reply from external director: %v", err)
+		return nil
+	}
+	return actions
+}
+
+func (director *Director) End() {
+	if director.process == nil {
+		return
+	}
+
+	director.stdin.Close()
+	if err := director.process.Wait(); err != nil {
+		logrus.Warnf("External director exited with error: %v", err)
+	}
+	director.process = nil
+}
+
+func (director *Director) start() error {
+	if len(director.command) == 0 {
+		return fmt.Errorf("no command given")
+	}
+
+	process := exec.Command(director.command[0], director.command[1:]...)
+	process.Stderr = os.Stderr
+
+	stdin, err := process.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := process.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := process.Start(); err != nil {
+		return err
+	}
+
+	director.process = process
+	director.stdin = stdin
+	director.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+func (director *Director) sendBoard(view game.BoardView) error {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("board %d %d %d %d\n",
+		view.Width(), view.Height(), view.NumMinesRemaining(), view.NumLivesRemaining()))
+
+	for y := uint(0); y < view.Height(); y++ {
+		for x := uint(0); x < view.Width(); x++ {
+			cell, _ := view.CellAt(x, y)
+			builder.WriteByte(cellGlyph(cell))
+		}
+		builder.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(director.stdin, builder.String())
+	return err
+}
+
+func cellGlyph(cell game.CellView) byte {
+	switch {
+	case cell.IsVoid():
+		return '-'
+	case cell.IsExploded():
+		return 'X'
+	case cell.IsFlagged():
+		return 'F'
+	case cell.IsQuestioned():
+		return '?'
+	case cell.IsRevealed():
+		return '0' + byte(cell.NumMines())
+	default:
+		return '#'
+	}
+}
+
+func (director *Director) receiveActions(view game.BoardView) ([]game.CellAction, error) {
+	actions := make([]game.CellAction, 0)
+
+	for {
+		line, err := director.stdout.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "end" {
+			return actions, nil
+		} else if line == "" {
+			continue
+		}
+
+		var verb string
+		var x, y uint
+		if _, err := fmt.Sscanf(line, "%s %d %d", &verb, &x, &y); err != nil {
+			return nil, fmt.Errorf("unable to parse %q: %w", line, err)
+		}
+
+		cell, isOnBoard := view.CellAt(x, y)
+		if !isOnBoard {
+			return nil, fmt.Errorf("(%d, %d) is not on the board", x, y)
+		}
+
+		switch verb {
+		case "click":
+			actions = append(actions, cell.Click())
+		case "flag":
+			actions = append(actions, cell.RightClick())
+		case "chord":
+			actions = append(actions, cell.MiddleClick())
+		case "flag-chord":
+			actions = append(actions, cell.FlagChord())
+		case "question":
+			actions = append(actions, cell.Question())
+		default:
+			return nil, fmt.Errorf("unknown action %q", verb)
+		}
+	}
+}
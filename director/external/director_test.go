@@ -0,0 +1,69 @@
+package external
+
+import (
+	"github.com/they4kman/gosweep/game"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// Gives up straight away, after recording its PID in the file it's passed
+const givingUpScript = `echo $$ > "$1"
+while read -r header; do
+	set -- $header
+	i=0
+	while [ $i -lt $3 ]; do
+		read -r row
+		i=$((i + 1))
+	done
+	echo end
+done
+`
+
+func TestProcessEndsWhenDirectorGivesUp(t *testing.T) {
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run the director with")
+	}
+
+	dir := t.TempDir()
+	script, pidFile := filepath.Join(dir, "director.sh"), filepath.Join(dir, "director.pid")
+	if err := os.WriteFile(script, []byte(givingUpScript), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := game.NewGameConfig()
+	config.Width, config.Height, config.NumMines = 9, 9, 10
+	config.Director = game.NewSimpleDirector(New(strings.Join([]string{shell, script, pidFile}, " ")))
+
+	result, err := game.PlayHeadless(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.State != game.Ongoing {
+		t.Fatalf("the game ended %v, expected the director to give up", result.State)
+	}
+
+	rawPID, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatalf("the director never ran: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(rawPID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The process has been waited on, so no longer exists
+	process, err := os.FindProcess(pid)
+	if err == nil {
+		err = process.Signal(syscall.Signal(0))
+	}
+	if err == nil {
+		process.Kill()
+		t.Errorf("the director's process %d was still running after the game", pid)
+	}
+}
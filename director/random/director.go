@@ -4,44 +4,30 @@ import (
 	"github.com/they4kman/gosweep/game"
 )
 
+// Director clicks a random unrevealed, unflagged cell each step
 type Director struct {
-	game.BaseDirector
-
-	act chan chan<- game.CellAction
+	// Cells in the order they'll be clicked, shuffled on the first step
+	cells []game.CellView
 }
 
-func (director *Director) Init(board *game.Board) {
-	director.act = make(chan chan<- game.CellAction)
-
-	go func() {
-		unrevealedCells := make([]*game.Cell, board.NumCells())
-		i := 0
-		for cell := range board.Cells() {
-			unrevealedCells[i] = cell
-			i++
+func (director *Director) Next(view game.BoardView) []game.CellAction {
+	if director.cells == nil {
+		for _, cell := range view.Cells() {
+			if !cell.IsRevealed() {
+				director.cells = append(director.cells, cell)
+			}
 		}
 
-		board.Rand().Shuffle(len(unrevealedCells), func(i, j int) {
-			unrevealedCells[i], unrevealedCells[j] = unrevealedCells[j], unrevealedCells[i]
+		view.Rand().Shuffle(len(director.cells), func(i, j int) {
+			director.cells[i], director.cells[j] = director.cells[j], director.cells[i]
 		})
+	}
 
-		for actions := range director.act {
-			for _, cell := range unrevealedCells {
-				if !cell.IsRevealed() && !cell.IsFlagged() {
-					actions <- cell.Click()
-					break
-				}
-			}
-
-			close(actions)
+	for _, cell := range director.cells {
+		if !cell.IsRevealed() && !cell.IsFlagged() {
+			return []game.CellAction{cell.Click()}
 		}
-	}()
-}
-
-func (director *Director) Act(actions chan<- game.CellAction) {
-	director.act <- actions
-}
+	}
 
-func (director *Director) End() {
-	close(director.act)
+	return nil
 }
@@ -0,0 +1,179 @@
+// Package registry names the directors which may be chosen from the command
+// line, along with the options each accepts
+package registry
+
+import (
+	"fmt"
+	"github.com/they4kman/gosweep/director/constraint"
+	"github.com/they4kman/gosweep/director/external"
+	"github.com/they4kman/gosweep/director/random"
+	"github.com/they4kman/gosweep/game"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prefix of director specs which run an external program, as a shorthand for
+// the external director's command option (e.g. "exec:python3 director.py")
+const ExternalPrefix = "exec:"
+
+// Option is a setting a director accepts
+type Option struct {
+	Name        string
+	Description string
+	// Value used if the option isn't given (if empty, the option is required)
+	Default string
+}
+
+// Options holds the values of a director's options, by name
+type Options map[string]string
+
+// Entry is a director which may be chosen by name
+type Entry struct {
+	Name        string
+	Description string
+	Options     []Option
+
+	// Creates the director. All declared options are passed, with defaults
+	// filled in for any not given.
+	New func(options Options) (game.Director, error)
+}
+
+var entries = []Entry{
+	{
+		Name:        "constraint",
+		Description: "Deduces mines from what revealed numbers say together, and clicks the cell least likely to be a mine when stuck",
+		Options: []Option{
+			{Name: "chord", Description: "Whether to chord revealed numbers, rather than clicking or flagging each of their neighbors (true or false)", Default: "false"},
+			{Name: "guess", Description: "How to choose among the cells least likely to be mines: progress (those likeliest to open up the board) or random", Default: "progress"},
+			{Name: "self-check", Description: "Whether to check its observations are consistent after each step, stopping if they're not (true or false; for debugging)", Default: "false"},
+		},
+		New: func(options Options) (game.Director, error) {
+			chord, err := strconv.ParseBool(options["chord"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q for option chord (expected true or false)", options["chord"])
+			}
+			guess, isValid := constraint.GuessStrategies[options["guess"]]
+			if !isValid {
+				return nil, fmt.Errorf("invalid value %q for option guess (expected progress or random)", options["guess"])
+			}
+			selfCheck, err := strconv.ParseBool(options["self-check"])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q for option self-check (expected true or false)", options["self-check"])
+			}
+			return &constraint.Director{Chord: chord, Guess: guess, SelfCheck: selfCheck}, nil
+		},
+	},
+	{
+		Name:        "random",
+		Description: "Clicks random cells",
+		New: func(options Options) (game.Director, error) {
+			return game.NewSimpleDirector(&random.Director{}), nil
+		},
+	},
+	{
+		Name:        "external",
+		Description: "Runs an external program, which is sent the board each step, and replies with its actions (\"exec:<command>\" for short)",
+		Options: []Option{
+			{Name: "command", Description: "Program to run, followed by its arguments"},
+		},
+		New: func(options Options) (game.Director, error) {
+			return game.NewSimpleDirector(external.New(options["command"])), nil
+		},
+	},
+}
+
+// Entries returns all registered directors, sorted by name
+func Entries() []Entry {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+func Lookup(name string) (Entry, bool) {
+	for _, entry := range entries {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// New creates the named director with the options, after checking each is
+// one the director accepts
+func New(name string, options Options) (game.Director, error) {
+	entry, isRegistered := Lookup(name)
+	if !isRegistered {
+		return nil, fmt.Errorf("unknown director %q (see `gosweep directors`)", name)
+	}
+
+	allOptions := make(Options, len(entry.Options))
+	for _, option := range entry.Options {
+		allOptions[option.Name] = option.Default
+	}
+
+	for optionName, value := range options {
+		if _, isAccepted := allOptions[optionName]; !isAccepted {
+			return nil, fmt.Errorf("director %s has no option %q", name, optionName)
+		}
+		allOptions[optionName] = value
+	}
+
+	for _, option := range entry.Options {
+		if allOptions[option.Name] == "" {
+			return nil, fmt.Errorf("director %s requires option %q", name, option.Name)
+		}
+	}
+
+	return entry.New(allOptions)
+}
+
+// NewFromSpec creates a director from a spec of the form
+// <name>[:<option>=<value>[,<option>=<value>...]], or exec:<command>. Options
+// given in the spec are overridden by those passed.
+func NewFromSpec(spec string, options Options) (game.Director, error) {
+	name, specOptions, err := ParseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for optionName, value := range options {
+		specOptions[optionName] = value
+	}
+	return New(name, specOptions)
+}
+
+// ParseSpec splits a director spec into its name and options (see NewFromSpec)
+func ParseSpec(spec string) (string, Options, error) {
+	options := make(Options)
+
+	if command, isExternal := strings.CutPrefix(spec, ExternalPrefix); isExternal {
+		options["command"] = command
+		return "external", options, nil
+	}
+
+	name, rawOptions, hasOptions := strings.Cut(spec, ":")
+	if hasOptions {
+		for _, rawOption := range strings.Split(rawOptions, ",") {
+			optionName, value, err := ParseOption(rawOption)
+			if err != nil {
+				return "", nil, err
+			}
+			options[optionName] = value
+		}
+	}
+
+	return name, options, nil
+}
+
+// ParseOption splits an option of the form <name>=<value>
+func ParseOption(option string) (string, string, error) {
+	name, value, hasValue := strings.Cut(option, "=")
+	if !hasValue || name == "" {
+		return "", "", fmt.Errorf("invalid director option %q (expected <name>=<value>)", option)
+	}
+	return name, value, nil
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"flag"
+	"testing"
+)
+
+// Size of benchmarked boards, e.g. go test ./game -bench Neighbors -board-width 500
+var benchWidth = flag.Uint("board-width", 1000, "Width of benchmarked boards, in cells")
+var benchHeight = flag.Uint("board-height", 1000, "Height of benchmarked boards, in cells")
+
+func benchConfig() boardConfig {
+	return boardConfig{
+		Width:    *benchWidth,
+		Height:   *benchHeight,
+		NumLives: 1,
+		Mode:     Classic,
+	}
+}
+
+// withFilledBoard runs the benchmark within the event loop of a board with a
+// fifth of its cells mined
+func withFilledBoard(b *testing.B, f func(b *testing.B, board *Board)) {
+	config := benchConfig()
+	config.NumMines = config.Width * config.Height / 5
+
+	board := createFilledBoard(config)
+	defer board.stop()
+
+	board.do(func() {
+		board.indexCells()
+		b.ResetTimer()
+		f(b, board)
+	})
+}
+
+// BenchmarkCells compares the goroutine-per-call channels cells were once
+// traversed with to the precomputed tables used now
+func BenchmarkCells(b *testing.B) {
+	b.Run("goroutine", func(b *testing.B) {
+		withFilledBoard(b, func(b *testing.B, board *Board) {
+			for i := 0; i < b.N; i++ {
+				for range goroutineCells(board) {
+				}
+			}
+		})
+	})
+	b.Run("list", func(b *testing.B) {
+		withFilledBoard(b, func(b *testing.B, board *Board) {
+			for i := 0; i < b.N; i++ {
+				for range board.CellList() {
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkNeighbors(b *testing.B) {
+	b.Run("goroutine", func(b *testing.B) {
+		withFilledBoard(b, func(b *testing.B, board *Board) {
+			for i := 0; i < b.N; i++ {
+				for cell := range goroutineCells(board) {
+					for range goroutineNeighbors(cell) {
+					}
+				}
+			}
+		})
+	})
+	b.Run("list", func(b *testing.B) {
+		withFilledBoard(b, func(b *testing.B, board *Board) {
+			for i := 0; i < b.N; i++ {
+				for _, cell := range board.CellList() {
+					for range cell.NeighborList() {
+					}
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkFillMines(b *testing.B) {
+	config := benchConfig()
+	numMines := config.Width * config.Height / 5
+
+	b.Run("board", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			board := createBoard(config)
+			b.StartTimer()
+
+			board.do(func() {
+				board.placeMines(numMines)
+			})
+
+			b.StopTimer()
+			board.stop()
+			b.StartTimer()
+		}
+	})
+	b.Run("bitboard", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			board := newBitboard(config)
+			b.StartTimer()
+
+			board.fillMines(numMines)
+		}
+	})
+}
+
+func BenchmarkFlood(b *testing.B) {
+	config := benchConfig()
+
+	b.Run("board", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			board := createBoard(config)
+			board.do(board.indexCells)
+			b.StartTimer()
+
+			// Without any mines, the first click reveals the whole board
+			board.do(board.CellAt(0, 0).click)
+
+			b.StopTimer()
+			board.stop()
+			b.StartTimer()
+		}
+	})
+	b.Run("bitboard", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			board := newBitboard(config)
+			b.StartTimer()
+
+			board.click(0)
+		}
+	})
+}
+
+// goroutineCells sends all non-void cells from a new goroutine, as Cells once did
+func goroutineCells(board *Board) <-chan *Cell {
+	out := make(chan *Cell)
+	go func() {
+		for y := uint(0); y < board.height; y++ {
+			for x := uint(0); x < board.width; x++ {
+				if cell := board.CellAt(x, y); !cell.isVoid {
+					out <- cell
+				}
+			}
+		}
+		close(out)
+	}()
+	return out
+}
+
+// goroutineNeighbors sends the cell's neighbors from a new goroutine, as
+// Neighbors once did
+func goroutineNeighbors(cell *Cell) <-chan *Cell {
+	out := make(chan *Cell)
+	go func() {
+		for _, neighbor := range cell.appendNeighbors(make([]*Cell, 0, maxSelfNeighbors)) {
+			out <- neighbor
+		}
+		close(out)
+	}()
+	return out
+}
@@ -0,0 +1,350 @@
+package game
+
+import (
+	"github.com/they4kman/gosweep/minegen"
+	"github.com/they4kman/gosweep/util/lockedRand"
+	"math/bits"
+	"math/rand"
+	"strings"
+)
+
+// bitset is a fixed-size set of cell indexes, packed 64 to a word
+type bitset []uint64
+
+func newBitset(n uint) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+func (set bitset) has(idx uint32) bool {
+	return set[idx/64]&(1<<(idx%64)) != 0
+}
+
+func (set bitset) add(idx uint32) {
+	set[idx/64] |= 1 << (idx % 64)
+}
+
+func (set bitset) remove(idx uint32) {
+	set[idx/64] &^= 1 << (idx % 64)
+}
+
+// count returns the number of indexes in the set
+func (set bitset) count() uint {
+	n := 0
+	for _, word := range set {
+		n += bits.OnesCount64(word)
+	}
+	return uint(n)
+}
+
+// forEach calls f with every index in the set, in ascending order
+func (set bitset) forEach(f func(idx uint32)) {
+	for wordIdx, word := range set {
+		for word != 0 {
+			bit := uint32(bits.TrailingZeros64(word))
+			f(uint32(wordIdx)*64 + bit)
+			word &= word - 1
+		}
+	}
+}
+
+// bitboard is a compact board, for boards far too large to give each cell its
+// own Cell. Each cell takes only a few bits, plus a byte for its number of
+// surrounding mines.
+//
+// Unlike Board, a bitboard has no event loop, director or sprites; it's only
+// meant to be generated, played and solved headlessly, from one goroutine.
+type bitboard struct {
+	width, height uint32
+	numCells      uint32
+	mode          GameMode
+	rand          *rand.Rand
+	// Places mines, separately from rand (see Board.mineGenerator)
+	mineGenerator *minegen.Generator
+
+	mines, revealed, flagged bitset
+	// Cells outside the board's shape; nil if the board is rectangular
+	voids bitset
+	// Number of mines surrounding each cell
+	counts []uint8
+
+	state      BoardState
+	hasClicked bool
+	// Index of the mine which lost the game (only valid if the game was lost)
+	losingIdx uint32
+
+	numVoids, numMines, numFlags uint
+	numLives, numStrikes         uint
+	// Number of non-mine cells left to reveal, before the game is won
+	numRemaining uint
+
+	// Cells revealed or flagged since changes were last taken, in the order
+	// they changed
+	changes []uint32
+}
+
+func newBitboard(config boardConfig) *bitboard {
+	numCells := config.Width * config.Height
+
+	board := &bitboard{
+		width:    uint32(config.Width),
+		height:   uint32(config.Height),
+		numCells: uint32(numCells),
+		mode:     config.Mode,
+		rand:     lockedRand.NewFromSeed(config.Seed),
+
+		mineGenerator: minegen.New(config.Seed),
+
+		mines:    newBitset(numCells),
+		revealed: newBitset(numCells),
+		flagged:  newBitset(numCells),
+		counts:   make([]uint8, numCells),
+
+		state:    Ongoing,
+		numLives: config.NumLives,
+	}
+
+	if config.Shape != nil {
+		board.voids = newBitset(numCells)
+		for idx := uint32(0); idx < board.numCells; idx++ {
+			x, y := board.coords(idx)
+			if config.Shape.IsVoid(uint(x), uint(y)) {
+				board.voids.add(idx)
+				board.numVoids++
+			}
+		}
+	}
+
+	board.numRemaining = uint(board.numCells) - board.numVoids
+	return board
+}
+
+func (board *bitboard) coords(idx uint32) (uint32, uint32) {
+	return idx % board.width, idx / board.width
+}
+
+func (board *bitboard) isVoid(idx uint32) bool {
+	return board.voids != nil && board.voids.has(idx)
+}
+
+// neighbors returns the indexes of the cell's non-void neighbors, stored in buf
+func (board *bitboard) neighbors(idx uint32, buf *[8]uint32) []uint32 {
+	x, y := board.coords(idx)
+
+	minX, maxX := x, x
+	if x > 0 {
+		minX--
+	}
+	if x+1 < board.width {
+		maxX++
+	}
+
+	minY, maxY := y, y
+	if y > 0 {
+		minY--
+	}
+	if y+1 < board.height {
+		maxY++
+	}
+
+	neighbors := buf[:0]
+	for ny := minY; ny <= maxY; ny++ {
+		for nx := minX; nx <= maxX; nx++ {
+			neighborIdx := ny*board.width + nx
+			if neighborIdx != idx && !board.isVoid(neighborIdx) {
+				neighbors = append(neighbors, neighborIdx)
+			}
+		}
+	}
+	return neighbors
+}
+
+// fillMines places n mines in random non-void cells (see minegen)
+func (board *bitboard) fillMines(n uint) {
+	board.mineGenerator.PlaceMines(bitboardField{board: board}, uint32(n))
+
+	var buf [8]uint32
+	board.mines.forEach(func(idx uint32) {
+		for _, neighbor := range board.neighbors(idx, &buf) {
+			board.counts[neighbor]++
+		}
+	})
+
+	board.numRemaining -= board.numMines
+}
+
+func (board *bitboard) setMine(idx uint32, isMine bool) {
+	if board.mines.has(idx) == isMine {
+		return
+	}
+
+	var buf [8]uint32
+	if isMine {
+		board.mines.add(idx)
+		board.numMines++
+		board.numRemaining--
+
+		for _, neighbor := range board.neighbors(idx, &buf) {
+			board.counts[neighbor]++
+		}
+	} else {
+		board.mines.remove(idx)
+		board.numMines--
+		board.numRemaining++
+
+		for _, neighbor := range board.neighbors(idx, &buf) {
+			board.counts[neighbor]--
+		}
+	}
+}
+
+// clearSurroundingMines relocates any mines in or around the cell to random
+// cells elsewhere, as Board.clearSurroundingMines does
+func (board *bitboard) clearSurroundingMines(center uint32) {
+	var buf [8]uint32
+	surrounding := append(board.neighbors(center, &buf), center)
+
+	board.mineGenerator.ClearAround(bitboardField{board: board, countMines: true}, surrounding)
+}
+
+// clearOpening relocates any mines within two cells of the cell, as
+// Board.clearOpening does
+func (board *bitboard) clearOpening(center uint32) {
+	x, y := board.coords(center)
+	square := squareAround(board.width, board.height, x, y, 2, board.isVoid)
+
+	if !board.mineGenerator.ClearAround(bitboardField{board: board, countMines: true}, square) {
+		board.clearSurroundingMines(center)
+	}
+}
+
+func (board *bitboard) canPlay() bool {
+	return board.state == Ongoing
+}
+
+func (board *bitboard) click(idx uint32) {
+	if board.isVoid(idx) || !board.canPlay() {
+		return
+	}
+
+	if !board.hasClicked {
+		board.hasClicked = true
+
+		switch board.mode {
+		case Win7:
+			board.clearSurroundingMines(idx)
+		case WinXP:
+			minegen.MoveToFirstFree(bitboardField{board: board, countMines: true}, idx)
+		case Opening:
+			board.clearOpening(idx)
+		}
+	}
+
+	if board.revealed.has(idx) || board.flagged.has(idx) {
+		return
+	}
+
+	if board.mines.has(idx) {
+		board.revealed.add(idx)
+		board.changes = append(board.changes, idx)
+		board.numStrikes++
+
+		if board.numStrikes < board.numLives {
+			// Exploded mines are flagged, as with Cell.explode
+			board.flagged.add(idx)
+			board.numFlags++
+		} else {
+			board.losingIdx = idx
+			board.state = Lost
+		}
+		return
+	}
+
+	board.flood(idx)
+}
+
+// flood reveals the cell, then breadth-first reveals the neighbors of every
+// revealed cell without surrounding mines
+func (board *bitboard) flood(idx uint32) {
+	var buf [8]uint32
+
+	board.reveal(idx)
+	queue := []uint32{idx}
+	for len(queue) > 0 {
+		idx := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		if board.counts[idx] != 0 {
+			continue
+		}
+
+		for _, neighbor := range board.neighbors(idx, &buf) {
+			if !board.revealed.has(neighbor) && !board.flagged.has(neighbor) {
+				board.reveal(neighbor)
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+}
+
+// reveal reveals a cell known not to be a mine
+func (board *bitboard) reveal(idx uint32) {
+	board.revealed.add(idx)
+	board.changes = append(board.changes, idx)
+
+	board.numRemaining--
+	if board.numRemaining == 0 && board.canPlay() {
+		board.state = Won
+	}
+}
+
+func (board *bitboard) flag(idx uint32) {
+	if board.revealed.has(idx) || board.flagged.has(idx) || !board.canPlay() {
+		return
+	}
+
+	board.flagged.add(idx)
+	board.numFlags++
+	board.changes = append(board.changes, idx)
+}
+
+// takeChanges returns all cells revealed or flagged since it was last called.
+// The returned slice is only valid until the board next changes.
+func (board *bitboard) takeChanges() []uint32 {
+	changes := board.changes
+	board.changes = board.changes[:0]
+	return changes
+}
+
+// serialize encodes the board in the same format as Board.serialize
+func (board *bitboard) serialize() string {
+	builder := strings.Builder{}
+	builder.Grow(int(board.numCells + board.height))
+
+	for idx := uint32(0); idx < board.numCells; idx++ {
+		if idx != 0 && idx%board.width == 0 {
+			builder.WriteByte('\n')
+		}
+
+		isMine, isRevealed, isFlagged := board.mines.has(idx), board.revealed.has(idx), board.flagged.has(idx)
+		switch {
+		case board.isVoid(idx):
+			builder.WriteByte(voidGlyph)
+		case isMine && isRevealed && board.state == Lost && idx == board.losingIdx:
+			builder.WriteByte('*')
+		case isMine && isRevealed:
+			builder.WriteByte('X')
+		case isMine && isFlagged:
+			builder.WriteByte('F')
+		case isMine:
+			builder.WriteByte('O')
+		case isFlagged:
+			builder.WriteByte('f')
+		case isRevealed:
+			builder.WriteByte('.')
+		default:
+			builder.WriteByte('#')
+		}
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"math/bits"
+)
+
+// Number of random picks a guess makes, before scanning for an unknown cell
+const maxRandomGuessAttempts = 64
+
+// bitboardSolver plays a bitboard using only what each revealed number says
+// about its own neighbors: if all its mines are flagged, the rest of its
+// neighbors are safe; if it has as many unknown neighbors as unflagged mines,
+// they're all mines. When no number says anything more, a random cell is
+// clicked.
+//
+// This is much weaker than the constraint director, but needs no more memory
+// than the bitboard itself, so it can solve boards of any size.
+type bitboardSolver struct {
+	board *bitboard
+
+	// Revealed cells whose neighbors may have changed since they were last
+	// considered
+	pending   []uint32
+	isPending bitset
+
+	numGuesses uint
+}
+
+func newBitboardSolver(board *bitboard) *bitboardSolver {
+	return &bitboardSolver{
+		board:     board,
+		isPending: newBitset(uint(board.numCells)),
+	}
+}
+
+// solve plays the board until it's won or lost, starting from its center
+func (solver *bitboardSolver) solve() {
+	board := solver.board
+
+	solver.clickAnywhere(board.height/2*board.width + board.width/2)
+
+	for board.canPlay() {
+		solver.considerChanges()
+
+		if len(solver.pending) == 0 {
+			solver.guess()
+			continue
+		}
+
+		idx := solver.pending[len(solver.pending)-1]
+		solver.pending = solver.pending[:len(solver.pending)-1]
+		solver.isPending.remove(idx)
+
+		solver.deduce(idx)
+	}
+}
+
+// considerChanges queues every changed cell, along with its revealed
+// neighbors, to be deduced from
+func (solver *bitboardSolver) considerChanges() {
+	board := solver.board
+
+	var buf [8]uint32
+	for _, idx := range board.takeChanges() {
+		solver.queue(idx)
+		for _, neighbor := range board.neighbors(idx, &buf) {
+			solver.queue(neighbor)
+		}
+	}
+}
+
+func (solver *bitboardSolver) queue(idx uint32) {
+	board := solver.board
+	if !board.revealed.has(idx) || board.mines.has(idx) || solver.isPending.has(idx) {
+		return
+	}
+
+	solver.isPending.add(idx)
+	solver.pending = append(solver.pending, idx)
+}
+
+// deduce clicks or flags the revealed cell's unknown neighbors, if its number
+// determines what they are
+func (solver *bitboardSolver) deduce(idx uint32) {
+	board := solver.board
+
+	var buf, unknownBuf [8]uint32
+	numFlagged := uint8(0)
+	unknown := unknownBuf[:0]
+	for _, neighbor := range board.neighbors(idx, &buf) {
+		if board.flagged.has(neighbor) {
+			numFlagged++
+		} else if !board.revealed.has(neighbor) {
+			unknown = append(unknown, neighbor)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return
+	}
+
+	numUnflaggedMines := board.counts[idx] - numFlagged
+	if numUnflaggedMines == 0 {
+		for _, neighbor := range unknown {
+			board.click(neighbor)
+		}
+	} else if int(numUnflaggedMines) == len(unknown) {
+		for _, neighbor := range unknown {
+			board.flag(neighbor)
+		}
+	}
+}
+
+// guess clicks a random unknown cell
+func (solver *bitboardSolver) guess() {
+	board := solver.board
+	solver.numGuesses++
+
+	for i := 0; i < maxRandomGuessAttempts; i++ {
+		idx := uint32(board.rand.Int63n(int64(board.numCells)))
+		if solver.isUnknown(idx) {
+			board.click(idx)
+			return
+		}
+	}
+
+	// Few unknown cells remain, so random picks are unlikely to find one
+	solver.clickAnywhere(uint32(board.rand.Int63n(int64(board.numCells))))
+}
+
+// clickAnywhere clicks the first unknown cell at or after idx, wrapping around
+func (solver *bitboardSolver) clickAnywhere(idx uint32) {
+	board := solver.board
+	numWords := uint32(len(board.revealed))
+
+	// Known cells are skipped a whole word at a time
+	startWord := idx / 64
+	for i := uint32(0); i <= numWords; i++ {
+		wordIdx := (startWord + i) % numWords
+
+		known := board.revealed[wordIdx] | board.flagged[wordIdx]
+		if board.voids != nil {
+			known |= board.voids[wordIdx]
+		}
+		if i == 0 {
+			// Only consider cells at or after idx in its own word
+			known |= 1<<(idx%64) - 1
+		}
+
+		for unknown := ^known; unknown != 0; unknown &= unknown - 1 {
+			candidate := wordIdx*64 + uint32(bits.TrailingZeros64(unknown))
+			if candidate < board.numCells {
+				board.click(candidate)
+				return
+			}
+		}
+	}
+}
+
+// isUnknown returns whether the cell is neither revealed nor flagged
+func (solver *bitboardSolver) isUnknown(idx uint32) bool {
+	board := solver.board
+	return !board.isVoid(idx) && !board.revealed.has(idx) && !board.flagged.has(idx)
+}
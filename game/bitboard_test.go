@@ -0,0 +1,90 @@
+package game
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBitset(t *testing.T) {
+	set := newBitset(130)
+	indexes := []uint32{0, 63, 64, 65, 129}
+	for _, idx := range indexes {
+		set.add(idx)
+	}
+	set.add(64)
+
+	if set.count() != uint(len(indexes)) {
+		t.Errorf("count is %d, expected %d", set.count(), len(indexes))
+	}
+	for _, idx := range []uint32{1, 62, 66, 128} {
+		if set.has(idx) {
+			t.Errorf("expected %d not to be in the set", idx)
+		}
+	}
+
+	var visited []uint32
+	set.forEach(func(idx uint32) {
+		visited = append(visited, idx)
+	})
+	if !reflect.DeepEqual(visited, indexes) {
+		t.Errorf("visited %v, expected %v", visited, indexes)
+	}
+
+	set.remove(64)
+	set.remove(1)
+	if set.has(64) || !set.has(63) || !set.has(65) || set.count() != uint(len(indexes)-1) {
+		t.Errorf("removing 64 left %v", set)
+	}
+}
+
+// TestBitboardMatchesBoard checks a bitboard generates the same mines as a
+// Board of the same config, before and after the first click relocates them
+func TestBitboardMatchesBoard(t *testing.T) {
+	shape, err := LoadShape("#########\n###   ###\n##-----##\n#########\n#########\n#########")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, mode := range []GameMode{Classic, Win7, WinXP, Opening} {
+		for _, withShape := range []bool{false, true} {
+			for seed := int64(1); seed <= 5; seed++ {
+				config := boardConfig{Width: 9, Height: 6, NumMines: 15, NumLives: 1, Mode: mode, Seed: seed}
+				if withShape {
+					config.Shape = shape
+				}
+
+				name := fmt.Sprintf("mode=%d,shape=%v,seed=%d", mode, withShape, seed)
+				t.Run(name, func(t *testing.T) {
+					board := createFilledBoard(config)
+					defer board.stop()
+
+					bitboard := newBitboard(config)
+					bitboard.fillMines(config.NumMines)
+
+					var serialized string
+					board.do(func() { serialized = board.serialize() })
+					if bitboard.serialize() != serialized {
+						t.Fatalf("generated\n%s\nexpected\n%s", bitboard.serialize(), serialized)
+					}
+
+					// Click the first mine, so it's relocated
+					clickIdx := uint32(0)
+					for !bitboard.mines.has(clickIdx) {
+						clickIdx++
+					}
+					x, y := bitboard.coords(clickIdx)
+
+					board.do(func() {
+						board.CellAt(uint(x), uint(y)).click()
+						serialized = board.serialize()
+					})
+					bitboard.click(clickIdx)
+					if bitboard.serialize() != serialized {
+						t.Errorf("clicking (%d, %d) left\n%s\nexpected\n%s", x, y, bitboard.serialize(), serialized)
+					}
+				})
+			}
+		}
+	}
+}
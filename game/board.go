@@ -1,13 +1,16 @@
 package game
 
 import (
+	"fmt"
 	"github.com/faiface/pixel"
 	"github.com/gammazero/deque"
+	"github.com/they4kman/gosweep/minegen"
 	"github.com/they4kman/gosweep/util/collections"
 	"github.com/they4kman/gosweep/util/lockedRand"
+	"io"
 	"math/rand"
+	"sort"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -15,12 +18,31 @@ import (
 type boardConfig struct {
 	Width, Height uint
 	NumMines      uint
+	NumLives      uint
 	Mode          GameMode
+	// Whether right-clicks cycle through "?" marks, after flags
+	QuestionMarks bool
+	// Whether the player's left-clicks on revealed numbers chord, and their
+	// right-clicks flag chord (see Cell.flagChord)
+	ChordOnClick          bool
+	FlagChordOnRightClick bool
+
+	// Cells to leave out of the board (optional)
+	Shape *BoardShape
 
 	Seed int64
 
 	Director         Director
 	DirectorTickRate time.Duration
+	// Whether the director is stepped manually (with directorStep), rather
+	// than periodically, in the background
+	Headless bool
+	// Where to write the director's state each step, if it's an
+	// InspectableDirector (optional)
+	DirectorDump io.Writer
+	// Whether to check the director's beliefs against the board after each
+	// change, if it's a CheckableDirector, panicking if they're wrong
+	CheckDirector bool
 
 	OnGameEnd func(*Board)
 }
@@ -28,28 +50,61 @@ type boardConfig struct {
 type Board struct {
 	width, height uint // in number of cells
 	numMines      uint
+	numLives      uint
 	mode          GameMode
+	questionMarks bool
+
+	chordOnClick          bool
+	flagChordOnRightClick bool
 
 	initialSeed int64
 	rand        *rand.Rand
+	// Places mines, drawing from its own PRNG, so boards depend only on
+	// their seed (and not on how else the board's rand is used)
+	mineGenerator *minegen.Generator
+	// Whether the mines were placed by mineGenerator (rather than loaded from
+	// a snapshot, or edited), so the board may be recreated from its seed
+	isSeeded bool
 
 	state          BoardState
 	cells          [][]Cell
 	hasClicked     bool
 	numFlags       uint
+	numStrikes     uint
 	remainingCells collections.Set[*Cell]
 
-	revelations chan *Cell
-	actionGroup sync.WaitGroup
-
-	director             Director
-	directorTickRate     time.Duration
-	directorFrame        int64
-	directorAct          chan struct{}
-	directorPause        chan struct{}
-	directorStop         chan struct{}
-	directorActRequested *sync.Cond
-	directorCellChanges  chan *Cell
+	// Inputs the game has been played with (see GameStats)
+	stats          GameStats
+	firstInputTime time.Time
+	// Number of times any cell has changed state, so inputs which change
+	// nothing may be counted as wasted
+	numStateChanges uint
+
+	// Traversal tables (see neighbors.go)
+	isIndexed       bool
+	cellList        []*Cell
+	neighborOffsets []uint32
+	neighborTable   []*Cell
+	// Visited marks for flood(), compared against floodGeneration so they
+	// needn't be cleared between floods
+	floodMarks      []uint32
+	floodGeneration uint32
+
+	// Commands to perform in the event loop (see event_loop.go)
+	commands chan func()
+
+	director           Director
+	directorTickRate   time.Duration
+	directorHeadless   bool
+	directorFrame      int64
+	directorAct        chan struct{}
+	directorStop       chan struct{}
+	directorStepQueued atomic.Bool
+	directorDump       io.Writer
+	checkDirector      bool
+	// Cells changed since the director last acted, in the order they changed
+	directorCellChanges    []*Cell
+	directorCellChangesSet collections.Set[*Cell]
 
 	directorAnnotations deque.Deque[Annotation]
 
@@ -72,6 +127,17 @@ func (board *Board) NumMinesRemaining() uint {
 	return board.numMines - board.numFlags
 }
 
+func (board *Board) NumLives() uint {
+	return board.numLives
+}
+
+func (board *Board) NumLivesRemaining() uint {
+	if board.numStrikes >= board.numLives {
+		return 0
+	}
+	return board.numLives - board.numStrikes
+}
+
 func (board *Board) Rand() *rand.Rand {
 	return board.rand
 }
@@ -83,49 +149,54 @@ func (board *Board) CellAt(x, y uint) *Cell {
 	return nil
 }
 
+// Cells sends all non-void cells. Prefer CellList where allocations matter.
 func (board *Board) Cells() <-chan *Cell {
-	out := make(chan *Cell)
-	go func() {
-		for y := uint(0); y < board.height; y++ {
-			for x := uint(0); x < board.width; x++ {
-				out <- board.CellAt(x, y)
-			}
-		}
-		close(out)
-	}()
-	return out
+	return sendCells(board.CellList())
 }
 
+// UnrevealedCells sends all unrevealed cells. Prefer AppendUnrevealedCells
+// where allocations matter.
 func (board *Board) UnrevealedCells() <-chan *Cell {
-	out := make(chan *Cell)
-	go func() {
-		for cell := range board.Cells() {
-			if !cell.isRevealed {
-				out <- cell
-			}
-		}
-		close(out)
-	}()
+	return sendCells(board.AppendUnrevealedCells(nil))
+}
+
+// sendCells sends each of the cells over a channel, which is closed afterward
+func sendCells(cells []*Cell) <-chan *Cell {
+	out := make(chan *Cell, len(cells))
+	for _, cell := range cells {
+		out <- cell
+	}
+	close(out)
 	return out
 }
 
 func (board *Board) TogglePaused() {
-	if board.state == Ongoing {
-		board.state = Paused
-	} else if board.state == Paused {
-		board.state = Ongoing
-	} else {
-		return
-	}
-	board.directorPause <- struct{}{}
+	board.do(func() {
+		if board.state == Ongoing {
+			board.state = Paused
+		} else if board.state == Paused {
+			board.state = Ongoing
+		}
+	})
 }
 
+// RequestDirectorAct queues a step of the director, unless one is already
+// queued. The step is skipped if the game is no longer ongoing by then.
 func (board *Board) RequestDirectorAct() {
-	if board.directorActRequested != nil {
-		board.directorActRequested.Broadcast()
+	if board.director == nil || !board.directorStepQueued.CompareAndSwap(false, true) {
+		return
 	}
+
+	board.post(func() {
+		board.directorStepQueued.Store(false)
+		if board.state == Ongoing {
+			board.directorStep()
+		}
+	})
 }
 
+// AddAnnotation displays an annotation atop a cell. Directors may only add
+// annotations while handling Act.
 func (board *Board) AddAnnotation(annotation Annotation) {
 	annotations := make(chan Annotation, 1)
 	annotations <- annotation
@@ -161,10 +232,20 @@ func (board *Board) serialize() string {
 }
 
 func (board *Board) snapshot() *BoardSnapshot {
-	return &BoardSnapshot{
+	snapshot := &BoardSnapshot{
 		Seed:            board.initialSeed,
+		Mode:            gameModeName(board.mode),
+		Lives:           board.numLives,
+		Preset:          board.presetName(),
+		Generator:       minegen.Version,
 		SerializedBoard: board.serialize(),
 	}
+
+	if board.state == Won || board.state == Lost {
+		stats := board.stats
+		snapshot.Stats = &stats
+	}
+	return snapshot
 }
 
 func (board *Board) screenToGridCoords(pos pixel.Vec) (uint, uint) {
@@ -180,186 +261,260 @@ func (board *Board) win() {
 	board.endGame()
 }
 
+// strike records a mine being revealed, and returns whether any lives remain
+// to continue playing. If not, the caller is expected to lose() the game.
+func (board *Board) strike() bool {
+	board.numStrikes++
+	return board.numStrikes < board.numLives
+}
+
 func (board *Board) lose() {
 	board.state = Lost
 	board.endGame()
 
-	wg := sync.WaitGroup{}
-
-	revealLost := func(cells <-chan *Cell) {
-		for cell := range cells {
-			cell.revealLost()
-		}
-		wg.Done()
+	for _, cell := range board.CellList() {
+		cell.revealLost()
 	}
+}
 
-	cells := board.Cells()
-
-	for i := 0; i < 4; i++ {
-		wg.Add(1)
-		go revealLost(cells)
+func (board *Board) endGame() {
+	var duration time.Duration
+	if !board.firstInputTime.IsZero() {
+		duration = time.Since(board.firstInputTime)
 	}
+	board.stats.finish(board.bbbv(), duration)
+	board.endDirector()
 
-	wg.Wait()
+	if board.onGameEnd != nil {
+		board.onGameEnd(board)
+	}
 }
 
-func (board *Board) endGame() {
-	if board.director != nil {
-		board.directorStop <- struct{}{}
+// endDirector stops the director acting, and ends it, if it hasn't been
+// already. It's called as the game ends, or as the board is stopped while the
+// game is still ongoing (e.g. a director giving up), so directors (and external
+// directors' processes) aren't leaked.
+func (board *Board) endDirector() {
+	if board.director != nil && board.directorStop != nil {
 		close(board.directorStop)
 		board.directorStop = nil
 
-		close(board.directorPause)
-		board.directorPause = nil
-
 		board.director.End()
 	}
-
-	if board.onGameEnd != nil {
-		board.onGameEnd(board)
-	}
 }
 
 func (board *Board) startGame() {
 	if board.director != nil {
-		board.director.Init(board)
-		board.director.actContinuously(board.directorTickRate, board.directorAct, board.directorStop)
+		board.director.Init(board.View())
+
+		if !board.directorHeadless {
+			board.director.actContinuously(board.directorTickRate, board.directorAct, board.directorStop)
+
+			go func() {
+				for {
+					select {
+					case <-board.directorAct:
+						board.RequestDirectorAct()
+					case <-board.directorStop:
+						return
+					}
+				}
+			}()
+		}
 
 		// Emit all cells to director at start of game
-		initialCells := make(chan *Cell, board.NumCells())
-		for cell := range board.Cells() {
-			initialCells <- cell
-		}
-		close(initialCells)
-		board.director.CellChanges(initialCells)
+		board.takeCellChanges()
+		board.director.CellChanges(sendCellViews(board.CellList()))
 	}
 }
 
 func (board *Board) markRevealed(cell *Cell) {
-	board.revelations <- cell
-}
+	delete(board.remainingCells, cell)
 
-func (board *Board) markChanged(cell *Cell) {
-	if board.directorCellChanges != nil {
-		board.directorCellChanges <- cell
+	if len(board.remainingCells) == 0 && board.canPlay() {
+		board.win()
 	}
 }
 
-func (board *Board) clearSurroundingMines(center *Cell) {
-	wg := sync.WaitGroup{}
+// takeCellChanges returns a channel of all cells changed since it was last
+// called, in the order they changed
+func (board *Board) takeCellChanges() <-chan CellView {
+	cellChanges := sendCellViews(board.directorCellChanges)
 
-	possibleRelocationsSet := make(collections.Set[*Cell])
-	for cell := range board.Cells() {
-		if !cell.isMine {
-			possibleRelocationsSet.Add(cell)
+	board.directorCellChanges = board.directorCellChanges[:0]
+	board.directorCellChangesSet = make(collections.Set[*Cell])
+
+	return cellChanges
+}
+
+// directorStep passes all changed cells to the director, then performs the
+// actions it chooses, returning the number of actions performed
+func (board *Board) directorStep() int {
+	board.director.CellChanges(board.takeCellChanges())
+	board.checkDirectorConstraints()
+
+	actions := make(chan CellAction, board.NumCells())
+	board.directorFrame++
+	go board.director.Act(actions)
+
+	dedupedActions := make([]CellAction, 0)
+	seenActions := make(collections.Set[CellAction])
+	for cellAction := range actions {
+		if !seenActions.Contains(cellAction) {
+			seenActions.Add(cellAction)
+			dedupedActions = append(dedupedActions, cellAction)
 		}
 	}
 
-	decreaseNumMines := make(chan *Cell)
-	wg.Add(1)
-	go func() {
-		for cell := range decreaseNumMines {
-			atomic.AddUint32(&cell.numMines, ^uint32(0))
+	// Perform actions in a consistent order, regardless of the order the
+	// director happened to choose them in
+	sort.Slice(dedupedActions, func(i, j int) bool {
+		a, b := dedupedActions[i], dedupedActions[j]
+		return a.cell.idx < b.cell.idx || (a.cell.idx == b.cell.idx && a.action < b.action)
+	})
+
+	board.dumpDirector()
+
+	for _, cellAction := range dedupedActions {
+		annotation := Annotation{
+			Type:       AnnotationType(cellAction.action),
+			cell:       cellAction.cell,
+			frame:      board.directorFrame,
+			firstShown: time.Now(),
 		}
-		wg.Done()
-	}()
+		board.directorAnnotations.PushBack(annotation)
+
+		cellAction.perform()
+	}
 
-	numSurroundingMines := 0
-	for cell := range center.SelfNeighbors() {
-		delete(possibleRelocationsSet, cell)
+	return len(dedupedActions)
+}
 
-		if cell.isMine {
-			numSurroundingMines++
+// dumpDirector writes the director's state to the board's DirectorDump, if any
+func (board *Board) dumpDirector() {
+	inspectable, isInspectable := board.director.(InspectableDirector)
+	if board.directorDump == nil || !isInspectable {
+		return
+	}
 
-			cell.isMine = false
-			board.remainingCells.Add(cell)
+	fmt.Fprintf(board.directorDump, "Step %d:\n", board.directorFrame)
+	inspectable.Dump(board.directorDump)
+}
 
-			cell.SendNeighbors(decreaseNumMines)
+// checkDirectorConstraints panics if the director is checked (see
+// boardConfig.CheckDirector), and any of its constraints is wrong
+func (board *Board) checkDirectorConstraints() {
+	checkable, isCheckable := board.director.(CheckableDirector)
+	if !board.checkDirector || !isCheckable {
+		return
+	}
+
+	for _, constraint := range checkable.Constraints() {
+		if err := constraint.check(); err != nil {
+			var dump strings.Builder
+			if inspectable, isInspectable := board.director.(InspectableDirector); isInspectable {
+				inspectable.Dump(&dump)
+			}
+			panic(fmt.Sprintf("director check failed at step %d: %s\n%s", board.directorFrame, err, dump.String()))
 		}
 	}
-	close(decreaseNumMines)
+}
 
-	possibleRelocations := make([]*Cell, len(possibleRelocationsSet))
+func (board *Board) markChanged(cell *Cell) {
+	if board.director != nil && !board.directorCellChangesSet.Contains(cell) {
+		board.directorCellChangesSet.Add(cell)
+		board.directorCellChanges = append(board.directorCellChanges, cell)
+	}
+}
 
-	i := 0
-	for cell := range possibleRelocationsSet {
-		possibleRelocations[i] = cell
-		i++
+// clearSurroundingMines relocates any mines in or around the cell to random
+// cells elsewhere (see minegen.Generator.ClearAround)
+func (board *Board) clearSurroundingMines(center *Cell) {
+	surrounding := make([]uint32, 0, maxSelfNeighbors)
+	for _, cell := range center.SelfNeighborList() {
+		surrounding = append(surrounding, uint32(cell.idx))
 	}
 
-	board.rand.Shuffle(len(possibleRelocations), func(i, j int) {
-		possibleRelocations[i], possibleRelocations[j] = possibleRelocations[j], possibleRelocations[i]
-	})
+	board.mineGenerator.ClearAround(boardField{board}, surrounding)
+}
 
-	increaseNumMines := make(chan *Cell)
-	wg.Add(1)
-	go func() {
-		for cell := range increaseNumMines {
-			atomic.AddUint32(&cell.numMines, 1)
-		}
-		wg.Done()
-	}()
+// moveMineToFirstFree moves the mine in the cell, if any, to the top-left-most
+// free cell (see minegen.MoveToFirstFree)
+func (board *Board) moveMineToFirstFree(cell *Cell) {
+	minegen.MoveToFirstFree(boardField{board}, uint32(cell.idx))
+}
 
-	for i := 0; i < numSurroundingMines; i++ {
-		cell := possibleRelocations[i]
-		cell.isMine = true
-		delete(board.remainingCells, cell)
+// clearOpening relocates any mines within two cells of the cell, so it and its
+// neighbors are all zeros, or as clearSurroundingMines does, if there's no room
+func (board *Board) clearOpening(center *Cell) {
+	square := squareAround(uint32(board.width), uint32(board.height), uint32(center.x), uint32(center.y), 2,
+		boardField{board}.IsVoid)
 
-		cell.SendNeighbors(increaseNumMines)
+	if !board.mineGenerator.ClearAround(boardField{board}, square) {
+		board.clearSurroundingMines(center)
 	}
-	close(increaseNumMines)
+}
 
-	wg.Wait()
+// placeMines places n mines in random non-void cells (see minegen)
+func (board *Board) placeMines(n uint) {
+	board.mineGenerator.PlaceMines(boardField{board}, uint32(n))
 }
 
-func (board *Board) fillMines(cells <-chan *Cell) {
-	mineNeighbors := make(chan *Cell)
+// setMine places or clears a mine in the cell, updating the counts of its
+// neighbors
+func (board *Board) setMine(cell *Cell, isMine bool) {
+	if cell.isMine == isMine {
+		return
+	}
+
+	cell.isMine = isMine
+	if isMine {
+		board.numMines++
+		delete(board.remainingCells, cell)
+	} else {
+		board.numMines--
+		board.remainingCells.Add(cell)
+	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		for cell := range mineNeighbors {
-			atomic.AddUint32(&cell.numMines, 1)
+	for _, neighbor := range cell.NeighborList() {
+		if isMine {
+			neighbor.numMines++
+		} else {
+			neighbor.numMines--
+		}
 
-			if cell.isRevealed {
-				cell.setState(CellState(cell.numMines))
-			}
+		if neighbor.isRevealed {
+			neighbor.setState(CellState(neighbor.numMines))
 		}
-		wg.Done()
-	}()
+	}
+}
 
+func (board *Board) fillMines(cells <-chan *Cell) {
 	for cell := range cells {
 		cell.isMine = true
 		board.numMines++
 		delete(board.remainingCells, cell)
 
-		cell.SendNeighbors(mineNeighbors)
-	}
-	close(mineNeighbors)
-
-	wg.Wait()
-}
-
-func (board *Board) randomCells(n uint) <-chan *Cell {
-	cells := make(chan *Cell, n)
+		for _, neighbor := range cell.NeighborList() {
+			neighbor.numMines++
 
-	numCells := board.height * board.width
-	cellIndexes := make([]uint, numCells)
-	for cellIdx := uint(0); cellIdx < numCells; cellIdx++ {
-		cellIndexes[cellIdx] = cellIdx
+			if neighbor.isRevealed {
+				neighbor.setState(CellState(neighbor.numMines))
+			}
+		}
 	}
+}
 
-	board.rand.Shuffle(len(cellIndexes), func(i, j int) {
-		cellIndexes[i], cellIndexes[j] = cellIndexes[j], cellIndexes[i]
-	})
-	for i := uint(0); i < n; i++ {
-		cellIdx := cellIndexes[i]
-		y, x := cellIdx/board.width, cellIdx%board.width
-		cells <- board.CellAt(x, y)
-	}
+// MaxBoardCells is the most cells a board may have, as each cell of a board
+// is held in memory in full (see bitboard for larger boards)
+const MaxBoardCells = 2048 * 2048
 
-	close(cells)
-	return cells
+// BoardFits returns whether a board of the size may be created: it must have
+// at least one cell, and at most MaxBoardCells
+func BoardFits(width, height uint) bool {
+	// Sides are checked before their product, which may otherwise overflow
+	return width > 0 && height > 0 && width <= MaxBoardCells && height <= MaxBoardCells &&
+		width*height <= MaxBoardCells
 }
 
 func createBoard(config boardConfig) *Board {
@@ -367,10 +522,16 @@ func createBoard(config boardConfig) *Board {
 		width:    config.Width,
 		height:   config.Height,
 		numMines: 0, // this will be set to its final value by fillMines
+		numLives: config.NumLives,
 		mode:     config.Mode,
 
-		initialSeed: config.Seed,
-		rand:        lockedRand.NewFromSeed(config.Seed),
+		questionMarks:         config.QuestionMarks,
+		chordOnClick:          config.ChordOnClick,
+		flagChordOnRightClick: config.FlagChordOnRightClick,
+
+		initialSeed:   config.Seed,
+		rand:          lockedRand.NewFromSeed(config.Seed),
+		mineGenerator: minegen.New(config.Seed),
 
 		state:          Ongoing,
 		cells:          make([][]Cell, config.Height),
@@ -378,93 +539,23 @@ func createBoard(config boardConfig) *Board {
 		numFlags:       0,
 		remainingCells: make(collections.Set[*Cell]),
 
-		actionGroup: sync.WaitGroup{},
-		revelations: make(chan *Cell),
+		commands: make(chan func(), commandQueueSize),
 
-		director:         config.Director,
-		directorTickRate: config.DirectorTickRate,
+		director:               config.Director,
+		directorTickRate:       config.DirectorTickRate,
+		directorHeadless:       config.Headless,
+		directorDump:           config.DirectorDump,
+		checkDirector:          config.CheckDirector,
+		directorCellChangesSet: make(collections.Set[*Cell]),
 
 		onGameEnd: config.OnGameEnd,
 	}
 
 	if config.Director != nil {
 		board.directorAct = make(chan struct{})
-		board.directorPause = make(chan struct{})
-		board.directorStop = make(chan struct{}, 1)
-		board.directorActRequested = sync.NewCond(&sync.Mutex{})
-		board.directorCellChanges = make(chan *Cell, board.NumCells())
-
-		go func() {
-			// Allow the game to start paused
-			select {
-			case <-board.directorPause:
-				<-board.directorPause
-			default:
-			}
-
-			for {
-				select {
-				case <-board.directorAct:
-					board.RequestDirectorAct()
-				case <-board.directorPause:
-					<-board.directorPause
-				}
-			}
-		}()
-
-		go func() {
-			for {
-				board.directorActRequested.L.Lock()
-				board.directorActRequested.Wait()
-
-				if board.directorStop == nil {
-					return
-				}
-
-				cellChanges := board.directorCellChanges
-				board.directorCellChanges = make(chan *Cell, board.NumCells())
-
-				close(cellChanges)
-				board.director.CellChanges(cellChanges)
-
-				actions := make(chan CellAction, board.NumCells())
-				board.directorFrame++
-				go board.director.Act(actions)
-
-				dedupedActions := make(collections.Set[CellAction])
-				for cellAction := range actions {
-					dedupedActions.Add(cellAction)
-				}
-
-				for cellAction := range dedupedActions {
-					annotation := Annotation{
-						Type:       AnnotationType(cellAction.action),
-						Cell:       cellAction.cell,
-						frame:      board.directorFrame,
-						firstShown: time.Now(),
-					}
-					board.directorAnnotations.PushBack(annotation)
-
-					cellAction.perform()
-				}
-
-				board.directorActRequested.L.Unlock()
-			}
-		}()
+		board.directorStop = make(chan struct{})
 	}
 
-	// Perform all unrevealedCell modifications in a single goroutine, to avoid
-	// concurrent modifications
-	go func() {
-		for cell := range board.revelations {
-			delete(board.remainingCells, cell)
-
-			if len(board.remainingCells) == 0 {
-				board.win()
-			}
-		}
-	}()
-
 	cellIdx := uint(0)
 	for y := uint(0); y < config.Height; y++ {
 		row := make([]Cell, config.Width)
@@ -477,7 +568,10 @@ func createBoard(config boardConfig) *Board {
 			cell.x, cell.y = x, y
 			cell.isMine = false
 			cell.isLosingMine = false
+			cell.isExploded = false
+			cell.isVoid = false
 			cell.isFlagged = false
+			cell.isQuestioned = false
 			cell.isRevealed = false
 			cell.numMines = 0
 			cell.state = Unrevealed
@@ -490,11 +584,24 @@ func createBoard(config boardConfig) *Board {
 		}
 	}
 
+	if config.Shape != nil {
+		for _, cell := range board.CellList() {
+			if config.Shape.IsVoid(cell.x, cell.y) {
+				cell.setVoid()
+			}
+		}
+	}
+
+	go board.runEventLoop()
+
 	return &board
 }
 
 func createFilledBoard(config boardConfig) *Board {
 	board := createBoard(config)
-	board.fillMines(board.randomCells(config.NumMines))
+	board.do(func() {
+		board.placeMines(config.NumMines)
+		board.isSeeded = true
+	})
 	return board
 }
@@ -0,0 +1,353 @@
+package game
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/they4kman/gosweep/minegen"
+	"github.com/they4kman/gosweep/util/collections"
+	"hash/crc32"
+	"math"
+	"strings"
+)
+
+// Prefix of board codes, naming the version of their format
+const boardCodePrefix = "gs1-"
+
+// Flags of the board code format, saying which layouts follow the header
+const (
+	boardCodeHasVoids = 1 << iota
+	boardCodeHasMines
+)
+
+// BoardCode describes a board compactly, as text which may be shared and
+// pasted to play the exact same board. Usually, only the board's configuration
+// and seed are encoded, and its mines are generated from the seed; boards which
+// were edited or loaded from snapshots encode where each mine lies, instead.
+//
+// Codes are "gs1-", followed by base64url (without padding) of:
+//
+//	uvarint  mine generator version (see minegen)
+//	uvarint  game mode
+//	uvarint  width, height, mines, lives
+//	varint   seed
+//	byte     flags (1: voids follow, 2: mines follow)
+//	bytes    voids, then mines: one bit per cell, least significant bit first,
+//	         indexed y*width + x
+//	uint32   CRC-32 (IEEE) of all the above, big-endian
+type BoardCode struct {
+	Width, Height uint
+	NumMines      uint
+	Lives         uint
+	Mode          GameMode
+	Seed          int64
+	Generator     int
+
+	// Cells left out of the board; nil if the board is rectangular
+	voids bitset
+	// Cells holding mines; nil if mines are generated from the seed
+	mines bitset
+}
+
+// HasLayout returns whether the code says where each mine lies, rather than
+// having them generated from the seed
+func (code BoardCode) HasLayout() bool {
+	return code.mines != nil
+}
+
+func (code BoardCode) String() string {
+	flags := byte(0)
+	if code.voids != nil {
+		flags |= boardCodeHasVoids
+	}
+	if code.mines != nil {
+		flags |= boardCodeHasMines
+	}
+
+	data := make([]byte, 0, 64)
+	data = binary.AppendUvarint(data, uint64(code.Generator))
+	data = binary.AppendUvarint(data, uint64(code.Mode))
+	data = binary.AppendUvarint(data, uint64(code.Width))
+	data = binary.AppendUvarint(data, uint64(code.Height))
+	data = binary.AppendUvarint(data, uint64(code.NumMines))
+	data = binary.AppendUvarint(data, uint64(code.Lives))
+	data = binary.AppendVarint(data, code.Seed)
+	data = append(data, flags)
+
+	numCells := code.Width * code.Height
+	if code.voids != nil {
+		data = appendBitset(data, code.voids, numCells)
+	}
+	if code.mines != nil {
+		data = appendBitset(data, code.mines, numCells)
+	}
+
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(data))
+	return boardCodePrefix + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// appendBitset appends the first n bits of the set, packed 8 to a byte
+func appendBitset(data []byte, set bitset, n uint) []byte {
+	for i := uint(0); i < (n+7)/8; i++ {
+		data = append(data, byte(set[i/8]>>(8*(i%8))))
+	}
+	return data
+}
+
+// ParseBoardCode decodes a board code (as produced by BoardCode.String)
+func ParseBoardCode(s string) (BoardCode, error) {
+	encoded, hasPrefix := strings.CutPrefix(strings.TrimSpace(s), boardCodePrefix)
+	if !hasPrefix {
+		return BoardCode{}, fmt.Errorf("invalid board code: expected it to begin with %q", boardCodePrefix)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(data) < 4 {
+		return BoardCode{}, fmt.Errorf("invalid board code: it may have been cut short, or mistyped")
+	}
+
+	data, checksum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(data) != checksum {
+		return BoardCode{}, fmt.Errorf("invalid board code: it may have been cut short, or mistyped")
+	}
+
+	reader := boardCodeReader{data: data}
+	code := BoardCode{
+		Generator: int(reader.uvarint()),
+		Mode:      GameMode(reader.uvarint()),
+		Width:     uint(reader.uvarint()),
+		Height:    uint(reader.uvarint()),
+		NumMines:  uint(reader.uvarint()),
+		Lives:     uint(reader.uvarint()),
+		Seed:      reader.varint(),
+	}
+	flags := reader.byte()
+
+	if code.Generator > minegen.Version {
+		return BoardCode{}, fmt.Errorf("board code was made with mine generator version %d, but only version %d is supported",
+			code.Generator, minegen.Version)
+	}
+	if reader.err != nil {
+		return BoardCode{}, fmt.Errorf("invalid board code: %w", reader.err)
+	}
+	if gameModeName(code.Mode) == "" {
+		return BoardCode{}, fmt.Errorf("invalid board code: unknown game mode %d", code.Mode)
+	}
+	if !BoardFits(code.Width, code.Height) {
+		return BoardCode{}, fmt.Errorf("invalid board code: the board is %dx%d (at most %d cells are supported)",
+			code.Width, code.Height, MaxBoardCells)
+	}
+
+	numCells := code.Width * code.Height
+	if flags&boardCodeHasVoids != 0 {
+		code.voids = reader.bitset(numCells)
+	}
+	if flags&boardCodeHasMines != 0 {
+		code.mines = reader.bitset(numCells)
+	}
+
+	if reader.err != nil {
+		return BoardCode{}, fmt.Errorf("invalid board code: %w", reader.err)
+	}
+
+	numPlayable := numCells
+	if code.voids != nil {
+		numPlayable -= code.voids.count()
+	}
+	if code.NumMines > numPlayable {
+		return BoardCode{}, fmt.Errorf("invalid board code: it has %d mines, but only %d cells", code.NumMines, numPlayable)
+	}
+	return code, nil
+}
+
+type boardCodeReader struct {
+	data []byte
+	err  error
+}
+
+func (reader *boardCodeReader) uvarint() uint64 {
+	value, n := binary.Uvarint(reader.data)
+	if n <= 0 {
+		reader.fail()
+		return 0
+	}
+	reader.data = reader.data[n:]
+	return value
+}
+
+func (reader *boardCodeReader) varint() int64 {
+	value, n := binary.Varint(reader.data)
+	if n <= 0 {
+		reader.fail()
+		return 0
+	}
+	reader.data = reader.data[n:]
+	return value
+}
+
+func (reader *boardCodeReader) byte() byte {
+	if len(reader.data) < 1 {
+		reader.fail()
+		return 0
+	}
+	value := reader.data[0]
+	reader.data = reader.data[1:]
+	return value
+}
+
+// bitset reads n bits, packed 8 to a byte
+func (reader *boardCodeReader) bitset(n uint) bitset {
+	numBytes := (n + 7) / 8
+	if uint(len(reader.data)) < numBytes {
+		reader.fail()
+		return nil
+	}
+
+	set := newBitset(n)
+	for i := uint(0); i < numBytes; i++ {
+		set[i/8] |= uint64(reader.data[i]) << (8 * (i % 8))
+	}
+	reader.data = reader.data[numBytes:]
+
+	// Bits padding out the last byte are left out of the set
+	if n%64 != 0 {
+		set[len(set)-1] &= 1<<(n%64) - 1
+	}
+	return set
+}
+
+func (reader *boardCodeReader) fail() {
+	if reader.err == nil {
+		reader.err = fmt.Errorf("the code ended early")
+	}
+}
+
+// BoardCode returns the code of the board the config creates. If the board is
+// loaded from a snapshot, the code says where each of its mines lie.
+func (config GameConfig) BoardCode() (BoardCode, error) {
+	if config.Snapshot != nil {
+		config.Director = nil
+		board := config.loadSnapshot(config.Snapshot, true)
+		if board == nil {
+			return BoardCode{}, fmt.Errorf("unable to load snapshot")
+		}
+		defer board.stop()
+
+		var code BoardCode
+		board.do(func() {
+			code = board.code(true)
+		})
+		return code, nil
+	}
+
+	config.applyMineDensity()
+
+	code := BoardCode{
+		Width:     config.Width,
+		Height:    config.Height,
+		NumMines:  config.NumMines,
+		Lives:     config.Lives,
+		Mode:      config.Mode,
+		Seed:      config.Seed,
+		Generator: minegen.Version,
+	}
+
+	if config.Shape != nil {
+		code.Width, code.Height = config.Shape.Width(), config.Shape.Height()
+		code.voids = newBitset(code.Width * code.Height)
+		for idx := range config.Shape.voids {
+			code.voids.add(uint32(idx))
+		}
+	}
+
+	return code, nil
+}
+
+// code returns the board's code, saying where each of its mines lie if
+// withLayout is set, or if the board's mines weren't generated from its seed
+func (board *Board) code(withLayout bool) BoardCode {
+	code := BoardCode{
+		Width:     board.width,
+		Height:    board.height,
+		NumMines:  board.numMines,
+		Lives:     board.numLives,
+		Mode:      board.mode,
+		Seed:      board.initialSeed,
+		Generator: minegen.Version,
+	}
+
+	numCells := board.NumCells()
+	if uint(len(board.CellList())) != numCells {
+		code.voids = newBitset(numCells)
+		for y := range board.cells {
+			for x := range board.cells[y] {
+				if cell := &board.cells[y][x]; cell.isVoid {
+					code.voids.add(uint32(cell.idx))
+				}
+			}
+		}
+	}
+
+	if withLayout || !board.isSeeded {
+		code.mines = newBitset(numCells)
+		for _, cell := range board.CellList() {
+			if cell.isMine {
+				code.mines.add(uint32(cell.idx))
+			}
+		}
+	}
+
+	return code
+}
+
+// ApplyBoardCode sets up the config to create the board the code describes
+func (config *GameConfig) ApplyBoardCode(code BoardCode) {
+	config.Width, config.Height = code.Width, code.Height
+	config.NumMines = code.NumMines
+	config.MineDensity = math.NaN()
+	config.Lives = code.Lives
+	config.Mode = code.Mode
+	config.Seed = code.Seed
+	config.Shape = nil
+	config.Snapshot = nil
+
+	if code.voids != nil {
+		config.Shape = &BoardShape{
+			width:  code.Width,
+			height: code.Height,
+			voids:  make(collections.Set[uint]),
+		}
+		code.voids.forEach(func(idx uint32) {
+			config.Shape.voids.Add(uint(idx))
+		})
+	}
+
+	if code.mines != nil {
+		builder := strings.Builder{}
+		for y := uint(0); y < code.Height; y++ {
+			if y > 0 {
+				builder.WriteByte('\n')
+			}
+			for x := uint(0); x < code.Width; x++ {
+				idx := uint32(y*code.Width + x)
+				switch {
+				case code.voids != nil && code.voids.has(idx):
+					builder.WriteByte(voidGlyph)
+				case code.mines.has(idx):
+					builder.WriteByte('O')
+				default:
+					builder.WriteByte('#')
+				}
+			}
+		}
+
+		config.Snapshot = &BoardSnapshot{
+			Seed:            code.Seed,
+			Mode:            gameModeName(code.Mode),
+			Lives:           code.Lives,
+			Generator:       code.Generator,
+			SerializedBoard: builder.String(),
+		}
+		config.LoadSnapshotFresh = true
+	}
+}
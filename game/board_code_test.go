@@ -0,0 +1,140 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoardCodeRoundTrip(t *testing.T) {
+	config := NewGameConfig()
+	config.Width, config.Height, config.NumMines = 16, 9, 20
+	config.Lives = 3
+	config.Mode = Opening
+	config.Seed = -1234567890123
+
+	code, err := config.BoardCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseBoardCode(code.String())
+	if err != nil {
+		t.Fatalf("unable to parse %s: %v", code, err)
+	}
+	if parsed.String() != code.String() {
+		t.Errorf("re-encoded as %s, expected %s", parsed, code)
+	}
+	if parsed.Width != 16 || parsed.Height != 9 || parsed.NumMines != 20 || parsed.Lives != 3 ||
+		parsed.Mode != Opening || parsed.Seed != config.Seed || parsed.HasLayout() {
+		t.Errorf("parsed %+v, expected the config's board", parsed)
+	}
+}
+
+func TestBoardCodeRoundTripLayout(t *testing.T) {
+	shape, err := LoadShape("#########\n###   ###\n##-----##\n#########\n#########")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	board := createFilledBoard(boardConfig{
+		Width:    shape.Width(),
+		Height:   shape.Height(),
+		NumMines: 7,
+		NumLives: 1,
+		Mode:     WinXP,
+		Shape:    shape,
+		Seed:     42,
+	})
+	defer board.stop()
+
+	var code BoardCode
+	var mines []uint
+	board.do(func() {
+		code = board.code(true)
+		for _, cell := range board.CellList() {
+			if cell.isMine {
+				mines = append(mines, cell.idx)
+			}
+		}
+	})
+
+	parsed, err := ParseBoardCode(code.String())
+	if err != nil {
+		t.Fatalf("unable to parse %s: %v", code, err)
+	}
+	if !parsed.HasLayout() {
+		t.Fatal("expected the parsed code to hold the mine layout")
+	}
+
+	for idx := uint32(0); idx < uint32(shape.Width()*shape.Height()); idx++ {
+		if isVoid := shape.voids.Contains(uint(idx)); parsed.voids.has(idx) != isVoid {
+			t.Errorf("cell %d: void is %v, expected %v", idx, parsed.voids.has(idx), isVoid)
+		}
+	}
+
+	// The board the code describes has the very same mines
+	config := NewGameConfig()
+	config.ApplyBoardCode(parsed)
+	loaded := config.createBoard()
+	defer loaded.stop()
+
+	var loadedMines []uint
+	loaded.do(func() {
+		for _, cell := range loaded.CellList() {
+			if cell.isMine {
+				loadedMines = append(loadedMines, cell.idx)
+			}
+		}
+	})
+
+	if len(loadedMines) != len(mines) {
+		t.Fatalf("loaded %d mines, expected %d", len(loadedMines), len(mines))
+	}
+	for i := range mines {
+		if loadedMines[i] != mines[i] {
+			t.Fatalf("loaded mines at %v, expected %v", loadedMines, mines)
+		}
+	}
+}
+
+func TestParseBoardCodeRejects(t *testing.T) {
+	valid := BoardCode{Width: 9, Height: 9, NumMines: 10, Lives: 1, Mode: Win7, Seed: 1}
+
+	withVoids := valid
+	withVoids.voids = newBitset(81)
+	for idx := uint32(0); idx < 75; idx++ {
+		withVoids.voids.add(idx)
+	}
+
+	tests := []struct {
+		name  string
+		code  string
+		error string
+	}{
+		{"no prefix", "AQEJCQoB", "begin with"},
+		{"mistyped", strings.Replace(valid.String(), "A", "B", 1), "mistyped"},
+		{"cut short", valid.String()[:10], "cut short"},
+		{"unknown mode", func() string { code := valid; code.Mode = 99; return code.String() }(), "unknown game mode"},
+		{"empty", func() string { code := valid; code.Width = 0; return code.String() }(), "the board is 0x9"},
+		{"too large", func() string { code := valid; code.Width, code.Height = 1<<20, 1<<20; return code.String() }(), "at most"},
+		{"overflowing", func() string { code := valid; code.Width, code.Height = 1<<33, 1<<31; return code.String() }(), "at most"},
+		{"too many mines", func() string { code := valid; code.NumMines = 82; return code.String() }(), "82 mines"},
+		{"too many mines for voids", withVoids.String(), "only 6 cells"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseBoardCode(test.code)
+			if err == nil {
+				t.Fatalf("parsed %s, expected an error", test.code)
+			}
+			if !strings.Contains(err.Error(), test.error) {
+				t.Errorf("error %q, expected it to mention %q", err, test.error)
+			}
+		})
+	}
+
+	if _, err := ParseBoardCode(valid.String()); err != nil {
+		t.Errorf("unable to parse valid code: %v", err)
+	}
+}
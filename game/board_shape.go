@@ -0,0 +1,71 @@
+package game
+
+import (
+	"fmt"
+	"github.com/they4kman/gosweep/util/collections"
+	"strings"
+)
+
+// Glyph used in board snapshots and shapes to denote a cell which is not part
+// of the board
+const voidGlyph = '-'
+
+// BoardShape describes which cells of a rectangular grid are part of the board,
+// allowing irregularly-shaped boards, or boards with holes.
+type BoardShape struct {
+	width, height uint
+	voids         collections.Set[uint]
+}
+
+// LoadShape parses a textual board shape, where each line is a row of the
+// board. Spaces and '-' denote voids; any other character denotes a cell.
+// Lines shorter than the longest line are padded with voids.
+func LoadShape(in string) (*BoardShape, error) {
+	rows := strings.Split(strings.Trim(in, "\r\n"), "\n")
+
+	shape := BoardShape{
+		height: uint(len(rows)),
+		voids:  make(collections.Set[uint]),
+	}
+	for _, row := range rows {
+		if width := uint(len(strings.TrimRight(row, "\r"))); width > shape.width {
+			shape.width = width
+		}
+	}
+
+	if shape.width == 0 || shape.height == 0 {
+		return nil, fmt.Errorf("board shape is empty")
+	}
+
+	for y, row := range rows {
+		row = strings.TrimRight(row, "\r")
+		for x := uint(0); x < shape.width; x++ {
+			if x >= uint(len(row)) || row[x] == ' ' || row[x] == voidGlyph {
+				shape.voids.Add(uint(y)*shape.width + x)
+			}
+		}
+	}
+
+	if uint(len(shape.voids)) == shape.width*shape.height {
+		return nil, fmt.Errorf("board shape has no cells")
+	}
+
+	return &shape, nil
+}
+
+func (shape *BoardShape) Width() uint {
+	return shape.width
+}
+
+func (shape *BoardShape) Height() uint {
+	return shape.height
+}
+
+// NumCells returns the number of cells which are part of the board
+func (shape *BoardShape) NumCells() uint {
+	return shape.width*shape.height - uint(len(shape.voids))
+}
+
+func (shape *BoardShape) IsVoid(x, y uint) bool {
+	return x >= shape.width || y >= shape.height || shape.voids.Contains(y*shape.width+x)
+}
@@ -1,21 +1,43 @@
 package game
 
 import (
+	"fmt"
+	"github.com/they4kman/gosweep/minegen"
 	"gopkg.in/yaml.v2"
 	"strings"
 )
 
+// BoardSnapshot is a board as saved to a file. Generator is the version of the
+// mine generator (see minegen) which placed its mines, and which moves them on
+// the first click when the board is played fresh. Stats are recorded for
+// boards whose games have ended, but aren't loaded.
 type BoardSnapshot struct {
-	Seed            int64  `yaml:"seed"`
-	Mode            string `yaml:"mode"`
-	SerializedBoard string `yaml:"board,flow"`
+	Seed            int64      `yaml:"seed"`
+	Mode            string     `yaml:"mode"`
+	Lives           uint       `yaml:"lives,omitempty"`
+	Preset          string     `yaml:"preset,omitempty"`
+	Generator       int        `yaml:"generator,omitempty"`
+	Stats           *GameStats `yaml:"stats,omitempty"`
+	SerializedBoard string     `yaml:"board,flow"`
 }
 
 var gameModes = map[string]GameMode{
 	"win7":    Win7,
+	"winxp":   WinXP,
+	"opening": Opening,
 	"classic": Classic,
 }
 
+// gameModeName returns the name the mode is saved in snapshots with
+func gameModeName(mode GameMode) string {
+	for name, namedMode := range gameModes {
+		if namedMode == mode {
+			return name
+		}
+	}
+	return ""
+}
+
 func (snapshot *BoardSnapshot) Serialize() string {
 	out, err := yaml.Marshal(snapshot)
 	if err != nil {
@@ -29,31 +51,46 @@ func (snapshot *BoardSnapshot) CreateBoard(config boardConfig, fresh bool) *Boar
 	rows := strings.Split(strings.TrimSpace(snapshot.SerializedBoard), "\n")
 
 	config.Height = uint(len(rows))
-	config.Width = uint(len(rows[0]))
+	config.Width = 0
+	for _, row := range rows {
+		// Rows shorter than the widest are padded with voids
+		if uint(len(row)) > config.Width {
+			config.Width = uint(len(row))
+		}
+	}
 	if config.Height == 0 || config.Width == 0 {
 		return nil
 	}
 
 	config.Seed = snapshot.Seed
 	config.Mode = gameModes[snapshot.Mode]
+	if snapshot.Lives > 0 {
+		config.NumLives = snapshot.Lives
+	}
 	config.NumMines = 0 // this will be calculated after mines are filled
 	board := createBoard(config)
 
-	mineCells := make(chan *Cell, config.Height*config.Width)
+	board.do(func() {
+		mineCells := make(chan *Cell, config.Height*config.Width)
+
+		for y, row := range rows {
+			for x, c := range row {
+				cell := board.CellAt(uint(x), uint(y))
+				cell.deserialize(string(c), fresh)
 
-	for y, row := range rows {
-		for x, c := range row {
-			cell := board.CellAt(uint(x), uint(y))
-			cell.deserialize(string(c), fresh)
+				if cell.isMine {
+					mineCells <- cell
+				}
+			}
 
-			if cell.isMine {
-				mineCells <- cell
+			for x := uint(len(row)); x < config.Width; x++ {
+				board.CellAt(x, uint(y)).setVoid()
 			}
 		}
-	}
-	close(mineCells)
+		close(mineCells)
 
-	board.fillMines(mineCells)
+		board.fillMines(mineCells)
+	})
 
 	return board
 }
@@ -63,5 +100,9 @@ func LoadSnapshot(in string) (*BoardSnapshot, error) {
 	if err := yaml.Unmarshal([]byte(in), &snapshot); err != nil {
 		return nil, err
 	}
+	if snapshot.Generator > minegen.Version {
+		return nil, fmt.Errorf("snapshot was generated by mine generator version %d, but only version %d is supported",
+			snapshot.Generator, minegen.Version)
+	}
 	return &snapshot, nil
 }
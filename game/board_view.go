@@ -0,0 +1,179 @@
+package game
+
+import (
+	"math/rand"
+)
+
+// BoardView is what directors see of the board: only what a player could see,
+// and nothing which would change the board. Directors change the board solely
+// through the CellActions they choose.
+type BoardView struct {
+	board *Board
+}
+
+// View returns a read-only view of the board, as directors see it
+func (board *Board) View() BoardView {
+	return BoardView{board}
+}
+
+func (view BoardView) Width() uint {
+	return view.board.width
+}
+
+func (view BoardView) Height() uint {
+	return view.board.height
+}
+
+func (view BoardView) NumCells() uint {
+	return view.board.NumCells()
+}
+
+// NumMinesRemaining returns the number of mines less the number of flags, as
+// shown by the counter above the board
+func (view BoardView) NumMinesRemaining() uint {
+	return view.board.NumMinesRemaining()
+}
+
+func (view BoardView) NumLivesRemaining() uint {
+	return view.board.NumLivesRemaining()
+}
+
+// CellAt returns the cell at the coordinates, or false if they're outside the
+// board. Void cells are returned, but report IsVoid.
+func (view BoardView) CellAt(x, y uint) (CellView, bool) {
+	cell := view.board.CellAt(x, y)
+	return CellView{cell}, cell != nil
+}
+
+// Cells returns all non-void cells, in order from left-to-right, top-to-bottom
+func (view BoardView) Cells() []CellView {
+	return view.AppendCells(make([]CellView, 0, len(view.board.CellList())))
+}
+
+// AppendCells appends all non-void cells to cells, and returns the extended slice
+func (view BoardView) AppendCells(cells []CellView) []CellView {
+	for _, cell := range view.board.CellList() {
+		cells = append(cells, CellView{cell})
+	}
+	return cells
+}
+
+// Rand returns the board's random number generator. Directors must use it for
+// any randomness, so games remain reproducible from their seed.
+func (view BoardView) Rand() *rand.Rand {
+	return view.board.Rand()
+}
+
+// AddAnnotation displays an annotation atop a cell. Directors may only add
+// annotations while handling Act.
+func (view BoardView) AddAnnotation(annotation Annotation) {
+	view.board.AddAnnotation(annotation)
+}
+
+// CellView is what directors see of a cell. CellViews are comparable, and may
+// be used as map keys.
+type CellView struct {
+	cell *Cell
+}
+
+func (cell CellView) String() string {
+	return cell.cell.String()
+}
+
+func (cell CellView) X() uint {
+	return cell.cell.x
+}
+
+func (cell CellView) Y() uint {
+	return cell.cell.y
+}
+
+func (cell CellView) IsRevealed() bool {
+	return cell.cell.isRevealed
+}
+
+func (cell CellView) IsFlagged() bool {
+	return cell.cell.isFlagged
+}
+
+// IsQuestioned returns whether the cell is marked with a "?"
+func (cell CellView) IsQuestioned() bool {
+	return cell.cell.isQuestioned
+}
+
+func (cell CellView) IsVoid() bool {
+	return cell.cell.isVoid
+}
+
+// IsExploded returns whether the cell is a mine revealed at the cost of a life.
+// Exploded mines are also flagged.
+func (cell CellView) IsExploded() bool {
+	return cell.cell.isExploded
+}
+
+// NumMines returns the number of mines surrounding a revealed cell. Unrevealed
+// cells (and exploded mines) report zero.
+func (cell CellView) NumMines() uint32 {
+	if !cell.cell.isRevealed || cell.cell.isMine {
+		return 0
+	}
+	return cell.cell.numMines
+}
+
+// Neighbors returns the cell's non-void neighbors
+func (cell CellView) Neighbors() []CellView {
+	return cell.AppendNeighbors(make([]CellView, 0, maxSelfNeighbors-1))
+}
+
+// AppendNeighbors appends the cell's non-void neighbors to neighbors, and
+// returns the extended slice
+func (cell CellView) AppendNeighbors(neighbors []CellView) []CellView {
+	for _, neighbor := range cell.cell.NeighborList() {
+		neighbors = append(neighbors, CellView{neighbor})
+	}
+	return neighbors
+}
+
+func (cell CellView) Click() CellAction {
+	return cell.cell.Click()
+}
+
+func (cell CellView) RightClick() CellAction {
+	return cell.cell.RightClick()
+}
+
+func (cell CellView) MiddleClick() CellAction {
+	return cell.cell.MiddleClick()
+}
+
+// FlagChord flags all the unrevealed neighbors of a revealed number at once, if
+// they must all be mines. Along with MiddleClick, this lets a director act on
+// a whole number with a single action.
+func (cell CellView) FlagChord() CellAction {
+	return cell.cell.FlagChord()
+}
+
+// Question toggles the cell's "?" mark, replacing any flag (as RightClick
+// replaces any "?" mark with a flag)
+func (cell CellView) Question() CellAction {
+	return cell.cell.Question()
+}
+
+// Annotate returns an annotation of the type atop the cell
+func (cell CellView) Annotate(annotationType AnnotationType) Annotation {
+	return Annotation{
+		Type: annotationType,
+		cell: cell.cell,
+	}
+}
+
+// sendCellViews sends a view of each of the cells over a channel, which is
+// closed afterward
+func sendCellViews(cells []*Cell) <-chan CellView {
+	out := make(chan CellView, len(cells))
+	for _, cell := range cells {
+		out <- CellView{cell}
+	}
+	close(out)
+	return out
+}
@@ -3,8 +3,6 @@ package game
 import (
 	"fmt"
 	"github.com/faiface/pixel"
-	"sync"
-	"sync/atomic"
 )
 
 type Cell struct {
@@ -16,6 +14,15 @@ type Cell struct {
 
 	isMine, isRevealed, isFlagged bool
 	isLosingMine                  bool
+	// Whether the cell is marked with a "?", as a reminder to come back to it.
+	// Unlike flags, these have no bearing on play.
+	isQuestioned bool
+	// Whether the cell is a mine which was revealed, but did not lose the game
+	// (as lives remained). Exploded mines are always flagged.
+	isExploded bool
+	// Whether the cell is not part of the board at all (e.g. a hole, or space
+	// around an irregularly-shaped board). Void cells are never neighbors.
+	isVoid bool
 
 	state   CellState
 	sprite  *pixel.Sprite
@@ -28,17 +35,25 @@ func (cell *Cell) String() string {
 
 func (cell *Cell) serialize() string {
 	switch {
+	case cell.isVoid:
+		return string(voidGlyph)
 	case cell.isMine:
 		switch {
 		case cell.isLosingMine:
 			return "*"
+		case cell.isExploded:
+			return "X"
 		case cell.isFlagged:
 			return "F"
+		case cell.isQuestioned:
+			return "Q"
 		default:
 			return "O"
 		}
 	case cell.isFlagged:
 		return "f"
+	case cell.isQuestioned:
+		return "?"
 	case cell.isRevealed:
 		return "."
 	default:
@@ -48,7 +63,7 @@ func (cell *Cell) serialize() string {
 
 func (cell *Cell) deserialize(c string, fresh bool) bool {
 	switch c {
-	case "*", "F", "O":
+	case "*", "X", "F", "Q", "O":
 		cell.isMine = true
 
 		switch c {
@@ -58,13 +73,23 @@ func (cell *Cell) deserialize(c string, fresh bool) bool {
 				cell.isRevealed = true
 				cell.setState(MineLosing)
 			}
+		case "X":
+			if !fresh {
+				cell.isRevealed = true
+				cell.board.numStrikes++
+				cell.explode()
+			}
 		case "F":
 			cell.setFlagged(true)
+		case "Q":
+			cell.setQuestioned(true)
 		default:
 			cell.setState(Unrevealed)
 		}
 	case "f":
 		cell.setFlagged(true)
+	case "?":
+		cell.setQuestioned(true)
 	case ".":
 		cell.isRevealed = true
 		// NOTE: this state will very likely be incorrect, until cell numbers are recalculated
@@ -73,6 +98,8 @@ func (cell *Cell) deserialize(c string, fresh bool) bool {
 	case "#":
 		cell.isRevealed = false
 		cell.setState(Unrevealed)
+	case string(voidGlyph):
+		cell.setVoid()
 	default:
 		return false
 	}
@@ -96,62 +123,41 @@ func (cell *Cell) IsFlagged() bool {
 	return cell.isFlagged
 }
 
+// IsQuestioned returns whether the cell is marked with a "?"
+func (cell *Cell) IsQuestioned() bool {
+	return cell.isQuestioned
+}
+
+// IsVoid returns whether the cell lies outside the board's shape
+func (cell *Cell) IsVoid() bool {
+	return cell.isVoid
+}
+
+// IsExploded returns whether the cell is a mine revealed at the cost of a life.
+// Exploded mines are also flagged.
+func (cell *Cell) IsExploded() bool {
+	return cell.isExploded
+}
+
 func (cell *Cell) NumMines() uint32 {
 	return cell.numMines
 }
 
+// SelfNeighbors sends the cell, followed by its neighbors. Prefer
+// SelfNeighborList where allocations matter.
 func (cell *Cell) SelfNeighbors() <-chan *Cell {
-	out := make(chan *Cell)
-	go func() {
-		out <- cell
-		cell.SendNeighbors(out)
-		close(out)
-	}()
-	return out
+	return sendCells(cell.SelfNeighborList())
 }
 
+// Neighbors sends the cell's neighbors. Prefer NeighborList where allocations
+// matter.
 func (cell *Cell) Neighbors() <-chan *Cell {
-	out := make(chan *Cell)
-	go func() {
-		cell.SendNeighbors(out)
-		close(out)
-	}()
-	return out
+	return sendCells(cell.NeighborList())
 }
 
 func (cell *Cell) SendNeighbors(out chan<- *Cell) {
-	board := cell.board
-
-	isAtTopBorder := cell.y < 1
-	isAtBottomBorder := cell.y >= board.height-1
-
-	if cell.x >= 1 {
-		out <- board.CellAt(cell.x-1, cell.y)
-
-		if !isAtTopBorder {
-			out <- board.CellAt(cell.x-1, cell.y-1)
-		}
-		if !isAtBottomBorder {
-			out <- board.CellAt(cell.x-1, cell.y+1)
-		}
-	}
-
-	if cell.x < board.width-1 {
-		out <- board.CellAt(cell.x+1, cell.y)
-
-		if !isAtTopBorder {
-			out <- board.CellAt(cell.x+1, cell.y-1)
-		}
-		if !isAtBottomBorder {
-			out <- board.CellAt(cell.x+1, cell.y+1)
-		}
-	}
-
-	if !isAtTopBorder {
-		out <- board.CellAt(cell.x, cell.y-1)
-	}
-	if !isAtBottomBorder {
-		out <- board.CellAt(cell.x, cell.y+1)
+	for _, neighbor := range cell.NeighborList() {
+		out <- neighbor
 	}
 }
 
@@ -176,15 +182,35 @@ func (cell *Cell) MiddleClick() CellAction {
 	}
 }
 
+func (cell *Cell) FlagChord() CellAction {
+	return CellAction{
+		cell:   cell,
+		action: FlagChord,
+	}
+}
+
+func (cell *Cell) Question() CellAction {
+	return CellAction{
+		cell:   cell,
+		action: Question,
+	}
+}
+
 func (cell *Cell) click() {
-	cell.board.actionGroup.Add(1)
-	defer cell.board.actionGroup.Done()
+	if cell.isVoid || !cell.board.canPlay() {
+		return
+	}
 
 	if !cell.board.hasClicked {
 		cell.board.hasClicked = true
 
-		if cell.board.mode == Win7 {
+		switch cell.board.mode {
+		case Win7:
 			cell.board.clearSurroundingMines(cell)
+		case WinXP:
+			cell.board.moveMineToFirstFree(cell)
+		case Opening:
+			cell.board.clearOpening(cell)
 		}
 	}
 
@@ -198,16 +224,61 @@ func (cell *Cell) click() {
 }
 
 func (cell *Cell) rightClick() {
-	cell.board.actionGroup.Add(1)
-	defer cell.board.actionGroup.Done()
+	if !cell.board.canPlay() {
+		return
+	}
 
-	if !cell.isRevealed {
+	if !cell.isRevealed && !cell.isVoid {
 		cell.toggleFlagged()
 	}
 }
 
+// playerMiddleClick is the player's middle-click (or left and right click
+// together), chording the cell
+func (cell *Cell) playerMiddleClick() {
+	cell.act(MiddleClick)
+}
+
+// playerClick is the player's left-click, which chords revealed numbers if
+// enabled
+func (cell *Cell) playerClick() {
+	if cell.isRevealed && cell.board.chordOnClick {
+		cell.act(MiddleClick)
+	} else {
+		cell.act(Click)
+	}
+}
+
+// playerRightClick is the player's right-click, cycling the cell through
+// unrevealed, flagged, and (if enabled) questioned, or flag chording revealed
+// numbers (if enabled). Directors toggle flags and "?" marks separately
+// instead, with RightClick and Question.
+func (cell *Cell) playerRightClick() {
+	switch {
+	case cell.isRevealed && cell.board.flagChordOnRightClick:
+		cell.act(FlagChord)
+	case cell.isQuestioned || (cell.isFlagged && cell.board.questionMarks):
+		cell.act(Question)
+	default:
+		cell.act(RightClick)
+	}
+}
+
+// question toggles the cell's "?" mark, whether or not question marks are
+// enabled for right-clicks
+func (cell *Cell) question() {
+	if cell.isRevealed || cell.isVoid || !cell.board.canPlay() {
+		return
+	}
+
+	if cell.isFlagged {
+		cell.setFlagged(false)
+	}
+	cell.setQuestioned(!cell.isQuestioned)
+}
+
 func (cell *Cell) middleClick() {
-	if !cell.isRevealed {
+	if !cell.isRevealed || cell.isVoid || !cell.board.canPlay() {
 		return
 	}
 	if cell.isFlagged {
@@ -215,24 +286,50 @@ func (cell *Cell) middleClick() {
 	}
 
 	numFlaggedNeighbors := uint32(0)
-	for neighbor := range cell.Neighbors() {
+	for _, neighbor := range cell.NeighborList() {
 		if neighbor.isFlagged {
 			numFlaggedNeighbors++
 		}
 	}
 
 	if cell.numMines == numFlaggedNeighbors {
-		for neighbor := range cell.Neighbors() {
+		for _, neighbor := range cell.NeighborList() {
 			neighbor.click()
 		}
 	}
 }
 
+// flagChord flags all the unrevealed neighbors of a revealed number, if there
+// are only as many of them (counting exploded mines) as the number
+func (cell *Cell) flagChord() {
+	if !cell.isRevealed || cell.isMine || cell.isVoid || !cell.board.canPlay() {
+		return
+	}
+
+	numPossibleMines := uint32(0)
+	for _, neighbor := range cell.NeighborList() {
+		if !neighbor.isRevealed || neighbor.isExploded {
+			numPossibleMines++
+		}
+	}
+
+	if cell.numMines == numPossibleMines {
+		for _, neighbor := range cell.NeighborList() {
+			if !neighbor.isRevealed && !neighbor.isFlagged {
+				neighbor.setFlagged(true)
+			}
+		}
+	}
+}
+
 func (cell *Cell) toggleFlagged() {
 	cell.setFlagged(!cell.isFlagged)
 }
 
 func (cell *Cell) setFlagged(isFlagged bool) {
+	if isFlagged {
+		cell.isQuestioned = false
+	}
 	cell.isFlagged = isFlagged
 
 	if cell.isFlagged {
@@ -246,6 +343,21 @@ func (cell *Cell) setFlagged(isFlagged bool) {
 	cell.board.markChanged(cell)
 }
 
+func (cell *Cell) setQuestioned(isQuestioned bool) {
+	if cell.isQuestioned == isQuestioned {
+		return
+	}
+	cell.isQuestioned = isQuestioned
+
+	if cell.isQuestioned {
+		cell.setState(QuestionMark)
+	} else {
+		cell.setState(Unrevealed)
+	}
+
+	cell.board.markChanged(cell)
+}
+
 func (cell *Cell) setMine(isMine bool) {
 	wasMine := cell.isMine
 	if isMine == wasMine {
@@ -265,18 +377,9 @@ func (cell *Cell) setMine(isMine bool) {
 		delta = ^uint32(0)
 	}
 
-	mineNeighbors := make(chan *Cell)
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		for cell := range mineNeighbors {
-			atomic.AddUint32(&cell.numMines, delta)
-		}
-		wg.Done()
-	}()
-
-	cell.SendNeighbors(mineNeighbors)
-	wg.Wait()
+	for _, neighbor := range cell.NeighborList() {
+		neighbor.numMines += delta
+	}
 
 	cell.board.markChanged(cell)
 }
@@ -288,11 +391,16 @@ func (cell *Cell) reveal() {
 
 	if !cell.isRevealed {
 		cell.isRevealed = true
+		cell.isQuestioned = false
 
 		if cell.isMine {
-			cell.setState(MineLosing)
-			cell.isLosingMine = true
-			cell.board.lose()
+			if cell.board.strike() {
+				cell.explode()
+			} else {
+				cell.setState(MineLosing)
+				cell.isLosingMine = true
+				cell.board.lose()
+			}
 		} else {
 			cell.setState(CellState(cell.numMines))
 		}
@@ -302,6 +410,33 @@ func (cell *Cell) reveal() {
 	}
 }
 
+// explode marks a revealed mine as known, flagging it so it no longer counts
+// toward the remaining mines
+func (cell *Cell) explode() {
+	cell.isExploded = true
+	cell.setState(Mine)
+
+	if !cell.isFlagged {
+		cell.isFlagged = true
+		cell.board.numFlags++
+	}
+}
+
+// setVoid removes the cell from the board. This is expected to be called
+// only while the board is being set up, before any mines are placed.
+func (cell *Cell) setVoid() {
+	cell.isVoid = true
+	cell.isRevealed = false
+	cell.isFlagged = false
+	cell.isQuestioned = false
+	cell.state = Unrevealed
+	cell.sprite = nil
+	cell.isDirty = false
+
+	delete(cell.board.remainingCells, cell)
+	cell.board.isIndexed = false
+}
+
 func (cell *Cell) revealLost() {
 	if cell.isFlagged {
 		if !cell.isMine {
@@ -320,8 +455,8 @@ func (cell *Cell) cascadeEmpty() {
 		func(cell *Cell) {
 			cell.reveal()
 		},
-		func(cell *Cell) <-chan *Cell {
-			return cell.Neighbors()
+		func(cell *Cell) []*Cell {
+			return cell.NeighborList()
 		},
 	)
 }
@@ -331,5 +466,6 @@ func (cell *Cell) setState(state CellState) {
 		cell.state = state
 		cell.sprite = cellSprites[state]
 		cell.isDirty = true
+		cell.board.numStateChanges++
 	}
 }
@@ -0,0 +1,125 @@
+package game
+
+import (
+	"fmt"
+	"github.com/faiface/pixel"
+	"golang.org/x/image/colornames"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Colors are used to draw everything besides the cells themselves
+type Colors struct {
+	Background   color.RGBA
+	Text         color.RGBA
+	Won          color.RGBA
+	Lost         color.RGBA
+	SaveButton   color.RGBA
+	CellPosition color.RGBA
+	// Outlines of the cells related to the inspected cell
+	Inspect color.RGBA
+
+	// Annotations of each type
+	Click       color.RGBA
+	RightClick  color.RGBA
+	MiddleClick color.RGBA
+	Question    color.RGBA
+	Highlight   color.RGBA
+}
+
+func DefaultColors() Colors {
+	return Colors{
+		Background:   colornames.Gainsboro,
+		Text:         colornames.Black,
+		Won:          colornames.Green,
+		Lost:         colornames.Red,
+		SaveButton:   colornames.Darkblue,
+		CellPosition: colornames.Darkcyan,
+		Inspect:      colornames.Darkorange,
+
+		Click:       color.RGBA{R: 0xff, A: 0xff},
+		RightClick:  color.RGBA{B: 0xff, A: 0xff},
+		MiddleClick: color.RGBA{G: 0xff, A: 0xff},
+		Question:    color.RGBA{R: 0xff, B: 0xff, A: 0xff},
+		Highlight:   color.RGBA{R: 0xff, G: 0xff, A: 0xff},
+	}
+}
+
+// Color returns a pointer to the color with the name (as used in config files,
+// e.g. "background", "right-click"), or nil if there is none
+func (colors *Colors) Color(name string) *color.RGBA {
+	switch name {
+	case "background":
+		return &colors.Background
+	case "text":
+		return &colors.Text
+	case "won":
+		return &colors.Won
+	case "lost":
+		return &colors.Lost
+	case "save-button":
+		return &colors.SaveButton
+	case "cell-position":
+		return &colors.CellPosition
+	case "inspect":
+		return &colors.Inspect
+	case "click":
+		return &colors.Click
+	case "right-click":
+		return &colors.RightClick
+	case "middle-click":
+		return &colors.MiddleClick
+	case "question":
+		return &colors.Question
+	case "highlight":
+		return &colors.Highlight
+	default:
+		return nil
+	}
+}
+
+// annotation returns the base color annotations of the type are drawn with
+func (colors Colors) annotation(annotationType AnnotationType) pixel.RGBA {
+	switch annotationType {
+	case AnnotateClick:
+		return pixel.ToRGBA(colors.Click)
+	case AnnotateRightClick, AnnotateFlagChord:
+		return pixel.ToRGBA(colors.RightClick)
+	case AnnotateMiddleClick:
+		return pixel.ToRGBA(colors.MiddleClick)
+	case AnnotateQuestion:
+		return pixel.ToRGBA(colors.Question)
+	case AnnotateHighlightYellow:
+		return pixel.ToRGBA(colors.Highlight)
+	default:
+		return pixel.Alpha(0)
+	}
+}
+
+// ParseColor parses a color given as a name (e.g. "gainsboro"), or in hex as
+// #rrggbb or #rrggbbaa
+func ParseColor(s string) (color.RGBA, error) {
+	if hex, isHex := strings.CutPrefix(s, "#"); isHex {
+		if len(hex) == 6 {
+			hex += "ff"
+		}
+
+		value, err := strconv.ParseUint(hex, 16, 32)
+		if len(hex) != 8 || err != nil {
+			return color.RGBA{}, fmt.Errorf("invalid color %q (expected #rrggbb or #rrggbbaa)", s)
+		}
+
+		return color.RGBA{
+			R: uint8(value >> 24),
+			G: uint8(value >> 16),
+			B: uint8(value >> 8),
+			A: uint8(value),
+		}, nil
+	}
+
+	if named, isNamed := colornames.Map[strings.ToLower(s)]; isNamed {
+		return named, nil
+	}
+	return color.RGBA{}, fmt.Errorf("unknown color %q", s)
+}
@@ -19,6 +19,7 @@ const (
 	Mine
 	MineUnrevealed
 	MineLosing
+	QuestionMark
 )
 
 var CellStates = []CellState{
@@ -37,6 +38,7 @@ var CellStates = []CellState{
 	Mine,
 	MineUnrevealed,
 	MineLosing,
+	QuestionMark,
 }
 
 const (
@@ -48,4 +50,5 @@ const (
 	Won
 	Ongoing
 	Paused
+	Editing
 )
@@ -1,6 +1,10 @@
 package game
 
-import "time"
+import (
+	"fmt"
+	"io"
+	"time"
+)
 
 type Action int
 
@@ -8,6 +12,11 @@ const (
 	Click Action = iota
 	MiddleClick
 	RightClick
+	// Toggles a "?" mark on the cell, e.g. to tag cells under consideration
+	Question
+	// Flags all unrevealed neighbors of a revealed number, if they must all be
+	// mines
+	FlagChord
 )
 
 type CellAction struct {
@@ -16,15 +25,7 @@ type CellAction struct {
 }
 
 func (cellAction CellAction) perform() {
-	switch cellAction.action {
-	case Click:
-		cellAction.cell.click()
-	case MiddleClick:
-		cellAction.cell.middleClick()
-	case RightClick:
-		cellAction.cell.rightClick()
-	default:
-	}
+	cellAction.cell.act(cellAction.action)
 }
 
 type AnnotationType int
@@ -33,12 +34,15 @@ const (
 	AnnotateClick           = AnnotationType(Click)
 	AnnotateMiddleClick     = AnnotationType(MiddleClick)
 	AnnotateRightClick      = AnnotationType(RightClick)
+	AnnotateQuestion        = AnnotationType(Question)
+	AnnotateFlagChord       = AnnotationType(FlagChord)
 	AnnotateHighlightYellow = iota
 )
 
+// Annotation is drawn atop a cell. Directors create them with CellView.Annotate.
 type Annotation struct {
 	Type AnnotationType
-	Cell *Cell
+	cell *Cell
 
 	frame      int64
 	firstShown time.Time
@@ -46,7 +50,7 @@ type Annotation struct {
 
 type Director interface {
 	// Initialize the director
-	Init(*Board)
+	Init(BoardView)
 
 	// Perform zero or more actions, by sending CellAction messages on the
 	// channel, then closing it.
@@ -58,15 +62,77 @@ type Director interface {
 
 	// Called before Act() with a channel containing all the Cells that have
 	// changed since the last call to Act()
-	CellChanges(changes <-chan *Cell)
+	CellChanges(changes <-chan CellView)
 
 	// Cleanup
 	End()
 }
 
+// InspectableDirector is a Director whose state may be inspected, to debug it
+// (with the inspect key, or --dump-director)
+type InspectableDirector interface {
+	Director
+
+	// Write a description of the director's state
+	Dump(out io.Writer)
+
+	// Describe what the director knows of the cell, returning the cells
+	// related to it (e.g. those it's considered alongside), and lines
+	// describing how
+	Inspect(cell CellView) (related []CellView, lines []string)
+}
+
+// Constraint is a director's belief that between MinMines and MaxMines of the
+// cells are mines
+type Constraint struct {
+	Cells              []CellView
+	MinMines, MaxMines int
+}
+
+// CheckableDirector is a Director whose beliefs may be checked against the
+// true board after each change, to test it (see GameConfig.CheckDirector)
+type CheckableDirector interface {
+	Director
+
+	// Return what the director believes of the board's unrevealed, unflagged
+	// cells
+	Constraints() []Constraint
+}
+
+// check returns an error if the constraint is invalid, or doesn't hold on the
+// true board
+func (constraint Constraint) check() error {
+	if constraint.MinMines < 0 || constraint.MinMines > constraint.MaxMines || constraint.MaxMines > len(constraint.Cells) {
+		return fmt.Errorf("%s has invalid bounds", constraint)
+	}
+
+	numMines := 0
+	for _, cell := range constraint.Cells {
+		if cell.IsRevealed() || cell.IsFlagged() {
+			return fmt.Errorf("%s holds known cell %s", constraint, cell)
+		}
+		if cell.cell.isMine {
+			numMines++
+		}
+	}
+
+	if numMines < constraint.MinMines || numMines > constraint.MaxMines {
+		return fmt.Errorf("%s holds %d mines", constraint, numMines)
+	}
+	return nil
+}
+
+func (constraint Constraint) String() string {
+	mines := fmt.Sprintf("%d", constraint.MinMines)
+	if constraint.MaxMines != constraint.MinMines {
+		mines = fmt.Sprintf("%d-%d", constraint.MinMines, constraint.MaxMines)
+	}
+	return fmt.Sprintf("%s mines among %v", mines, constraint.Cells)
+}
+
 type BaseDirector struct{}
 
-func (director *BaseDirector) Init(*Board) {
+func (director *BaseDirector) Init(BoardView) {
 }
 
 func (director *BaseDirector) Act(chan<- CellAction) {
@@ -74,21 +140,25 @@ func (director *BaseDirector) Act(chan<- CellAction) {
 
 func (director *BaseDirector) actContinuously(tickRate time.Duration, act chan<- struct{}, done <-chan struct{}) {
 	go func() {
-		tick := time.Tick(tickRate)
+		ticker := time.NewTicker(tickRate)
+		defer ticker.Stop()
 
 		for {
 			select {
 			case <-done:
 				return
-			case <-tick:
-				act <- struct{}{}
-			default:
+			case <-ticker.C:
+				select {
+				case act <- struct{}{}:
+				case <-done:
+					return
+				}
 			}
 		}
 	}()
 }
 
-func (director *BaseDirector) CellChanges(changes <-chan *Cell) {
+func (director *BaseDirector) CellChanges(changes <-chan CellView) {
 }
 
 func (director *BaseDirector) End() {
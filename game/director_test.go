@@ -0,0 +1,81 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// beliefDirector believes whatever it's told of the board's cells
+type beliefDirector struct {
+	BaseDirector
+	constraints []Constraint
+}
+
+func (director *beliefDirector) Constraints() []Constraint {
+	return director.constraints
+}
+
+func TestCheckDirectorConstraints(t *testing.T) {
+	director := &beliefDirector{}
+	board := createFilledBoard(boardConfig{
+		Width:         9,
+		Height:        9,
+		NumMines:      10,
+		NumLives:      1,
+		Mode:          Classic,
+		Seed:          42,
+		Director:      director,
+		Headless:      true,
+		CheckDirector: true,
+	})
+	defer board.stop()
+
+	var mine, safe, revealed CellView
+	board.do(func() {
+		for _, cell := range board.CellList() {
+			if cell.isMine {
+				mine = CellView{cell}
+			} else if safe.cell == nil {
+				safe = CellView{cell}
+			} else if revealed.cell == nil {
+				cell.isRevealed = true
+				revealed = CellView{cell}
+			}
+		}
+	})
+
+	tests := []struct {
+		name       string
+		constraint Constraint
+		error      string
+	}{
+		{"right", Constraint{Cells: []CellView{mine, safe}, MinMines: 1, MaxMines: 1}, ""},
+		{"loose", Constraint{Cells: []CellView{mine, safe}, MinMines: 0, MaxMines: 2}, ""},
+		{"too few mines", Constraint{Cells: []CellView{mine, safe}, MinMines: 0, MaxMines: 0}, "holds 1 mines"},
+		{"too many mines", Constraint{Cells: []CellView{mine, safe}, MinMines: 2, MaxMines: 2}, "holds 1 mines"},
+		{"invalid bounds", Constraint{Cells: []CellView{safe}, MinMines: 1, MaxMines: 0}, "invalid bounds"},
+		{"known cell", Constraint{Cells: []CellView{safe, revealed}, MinMines: 0, MaxMines: 0}, "known cell"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			director.constraints = []Constraint{test.constraint}
+
+			var failure any
+			board.do(func() {
+				defer func() { failure = recover() }()
+				board.checkDirectorConstraints()
+			})
+
+			if test.error == "" {
+				if failure != nil {
+					t.Errorf("expected %s to hold, but: %v", test.constraint, failure)
+				}
+			} else if failure == nil {
+				t.Errorf("expected %s to fail the check", test.constraint)
+			} else if !strings.Contains(failure.(string), test.error) {
+				t.Errorf("check failed with %q, expected it to mention %q", failure, test.error)
+			}
+		})
+	}
+}
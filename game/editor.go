@@ -0,0 +1,146 @@
+package game
+
+// edit places the board in the editor, where mines, revelations and flags may
+// be freely toggled. The director is not started until editing is finished.
+func (board *Board) edit() {
+	board.state = Editing
+	board.isSeeded = false
+
+	for _, cell := range board.CellList() {
+		cell.setState(cell.editorState())
+	}
+}
+
+// finishEditing makes the edited board playable, as-is
+func (board *Board) finishEditing() {
+	if board.state != Editing {
+		return
+	}
+
+	// The layout was crafted deliberately, so the first click must not
+	// relocate any mines
+	board.hasClicked = true
+	board.state = Ongoing
+
+	for _, cell := range board.CellList() {
+		cell.setState(cell.playState())
+	}
+
+	board.startGame()
+}
+
+func (board *Board) toggleMine(cell *Cell) {
+	if cell.isVoid {
+		return
+	}
+
+	if cell.isRevealed {
+		board.toggleRevealed(cell)
+	}
+
+	cell.isMine = !cell.isMine
+
+	var delta uint32
+	if cell.isMine {
+		board.numMines++
+		delete(board.remainingCells, cell)
+		delta = 1
+	} else {
+		board.numMines--
+		board.remainingCells.Add(cell)
+		delta = ^uint32(0)
+	}
+
+	cell.setState(cell.editorState())
+	for _, neighbor := range cell.NeighborList() {
+		neighbor.numMines += delta
+		neighbor.setState(neighbor.editorState())
+	}
+}
+
+func (board *Board) toggleRevealed(cell *Cell) {
+	if cell.isVoid {
+		return
+	}
+
+	if cell.isRevealed {
+		cell.isRevealed = false
+
+		if cell.isMine {
+			if cell.isExploded {
+				cell.isExploded = false
+				board.numStrikes--
+			}
+			cell.isLosingMine = false
+		} else {
+			board.remainingCells.Add(cell)
+		}
+	} else if !cell.isMine {
+		if cell.isFlagged {
+			board.toggleFlagged(cell)
+		}
+
+		cell.isRevealed = true
+		cell.isQuestioned = false
+		delete(board.remainingCells, cell)
+	}
+
+	cell.setState(cell.editorState())
+}
+
+func (board *Board) toggleFlagged(cell *Cell) {
+	if cell.isRevealed || cell.isVoid {
+		return
+	}
+
+	cell.isFlagged = !cell.isFlagged
+	if cell.isFlagged {
+		cell.isQuestioned = false
+		board.numFlags++
+	} else {
+		board.numFlags--
+	}
+
+	cell.setState(cell.editorState())
+}
+
+// editorState returns the state displaying all the cell's information,
+// including whether it's a mine
+func (cell *Cell) editorState() CellState {
+	switch {
+	case cell.isFlagged && cell.isMine:
+		return Flag
+	case cell.isFlagged:
+		return FlagWrong
+	case cell.isLosingMine:
+		return MineLosing
+	case cell.isMine && cell.isRevealed:
+		return Mine
+	case cell.isMine:
+		return MineUnrevealed
+	case cell.isRevealed:
+		return CellState(cell.numMines)
+	case cell.isQuestioned:
+		return QuestionMark
+	default:
+		return Unrevealed
+	}
+}
+
+// playState returns the state displaying only what a player could see
+func (cell *Cell) playState() CellState {
+	switch {
+	case cell.isExploded:
+		return Mine
+	case cell.isFlagged:
+		return Flag
+	case cell.isLosingMine:
+		return MineLosing
+	case cell.isRevealed:
+		return CellState(cell.numMines)
+	case cell.isQuestioned:
+		return QuestionMark
+	default:
+		return Unrevealed
+	}
+}
@@ -0,0 +1,48 @@
+package game
+
+// Board state is only ever read or mutated from within the board's event loop,
+// which runs queued commands one at a time, in order. This keeps games free of
+// data races, and (for director games) reproducible from their seed.
+//
+// Unexported Board and Cell methods assume they're called from within the event
+// loop, and must not call do(), which would deadlock. Anything outside the
+// loop (the GUI, exports, tickers) must go through do() or post().
+//
+// Directors are called from within the event loop, and may read the board
+// freely while handling Init, CellChanges and Act.
+
+// Number of commands which may be queued before post() blocks
+const commandQueueSize = 64
+
+// runEventLoop performs queued commands until the loop is stopped
+func (board *Board) runEventLoop() {
+	for command := range board.commands {
+		if command == nil {
+			return
+		}
+		command()
+	}
+}
+
+// do queues the command on the event loop, and waits for it to be performed
+func (board *Board) do(command func()) {
+	done := make(chan struct{})
+	board.commands <- func() {
+		defer close(done)
+		command()
+	}
+	<-done
+}
+
+// post queues the command on the event loop, without waiting for it
+func (board *Board) post(command func()) {
+	board.commands <- command
+}
+
+// stop ends the event loop once all commands queued before it are performed,
+// first waiting for the board's director to be ended, if the game hadn't
+// already ended it. Commands queued afterward are never performed.
+func (board *Board) stop() {
+	board.do(board.endDirector)
+	board.commands <- nil
+}
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"fmt"
+	"github.com/they4kman/gosweep/util/collections"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"sort"
+	"time"
+)
+
+// Time the final board is shown for, before an exported GIF loops
+const finalFrameDelay = 2 * time.Second
+
+// ExportGIF plays a whole game with the config's director, without opening a
+// window, and writes it to out as an animated GIF, with one frame per director
+// frame. Each frame is overlaid with the director's annotations for that frame.
+func ExportGIF(config GameConfig, scale int, out io.Writer) error {
+	if config.Director == nil {
+		return fmt.Errorf("a director is required to export a game")
+	}
+
+	config.headless = true
+	config.applyMineDensity()
+
+	board := config.createBoard()
+	if board == nil {
+		return fmt.Errorf("unable to create board")
+	}
+	defer board.stop()
+
+	renderConfig := NewRenderConfig()
+	renderConfig.Scale = scale
+	renderConfig.AnnotationAlpha = config.AnnotationBaseAlpha
+	renderConfig.Colors = config.Colors
+
+	anim := gif.GIF{}
+	addFrame := func(annotations []Annotation, delay time.Duration) {
+		img := board.render(annotations, renderConfig)
+		anim.Image = append(anim.Image, toPaletted(img))
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+
+	board.do(func() {
+		board.playHeadless(
+			func() {
+				addFrame(nil, config.DirectorTickRate)
+			},
+			func() {
+				annotations := make([]Annotation, 0, board.directorAnnotations.Len())
+				for board.directorAnnotations.Len() > 0 {
+					annotations = append(annotations, board.directorAnnotations.PopFront())
+				}
+
+				addFrame(annotations, config.DirectorTickRate)
+			},
+		)
+
+		addFrame(nil, finalFrameDelay)
+	})
+
+	return gif.EncodeAll(out, &anim)
+}
+
+// toPaletted converts the image for use as a GIF frame, using its exact colors
+// if there are few enough of them
+func toPaletted(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+
+	colors := make(collections.Set[color.RGBA])
+	for y := bounds.Min.Y; y < bounds.Max.Y && len(colors) <= 256; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X && len(colors) <= 256; x++ {
+			colors.Add(color.RGBAModel.Convert(img.At(x, y)).(color.RGBA))
+		}
+	}
+
+	var framePalette color.Palette
+	if len(colors) <= 256 {
+		exactColors := make([]color.RGBA, 0, len(colors))
+		for c := range colors {
+			exactColors = append(exactColors, c)
+		}
+
+		// Ensure identical frames are encoded identically
+		sort.Slice(exactColors, func(i, j int) bool {
+			a, b := exactColors[i], exactColors[j]
+			return uint32(a.R)<<24|uint32(a.G)<<16|uint32(a.B)<<8|uint32(a.A) <
+				uint32(b.R)<<24|uint32(b.G)<<16|uint32(b.B)<<8|uint32(b.A)
+		})
+
+		framePalette = make(color.Palette, len(exactColors))
+		for i, c := range exactColors {
+			framePalette[i] = c
+		}
+	} else {
+		framePalette = palette.Plan9
+	}
+
+	paletted := image.NewPaletted(bounds, framePalette)
+	draw.Draw(paletted, bounds, img, bounds.Min, draw.Src)
+	return paletted
+}
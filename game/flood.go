@@ -1,78 +1,40 @@
-// Shamelessly stolen from: https://github.com/hinshun/floodfill
-
 package game
 
-import (
-	"github.com/they4kman/gosweep/util/collections"
-	"sync"
-)
-
-const DefaultParallelism = 20
-
-type NeighborGetter func(*Cell) <-chan *Cell
+type NeighborGetter func(*Cell) []*Cell
 type Visitor func(*Cell)
 
+// flood visits the cell, then breadth-first visits the neighbors of every
+// visited cell without surrounding mines. Cells are visited one at a time, in
+// a consistent order, so the board's event loop stays deterministic.
 func flood(cell *Cell, visit Visitor, getNeighbors NeighborGetter) {
-	parallelFlood(cell, DefaultParallelism, visit, getNeighbors)
-}
-
-func parallelFlood(cell *Cell, parallelism int, visit Visitor, getNeighbors NeighborGetter) {
-	visited := make(collections.Set[uint])
-	visitQueue := make([]*Cell, 0)
-	visitLock := sync.Mutex{}
-	permitCh := make(chan struct{}, parallelism)
-	wg := sync.WaitGroup{}
-
-	var visitNext func()
-
-	enqueue := func(cell *Cell) {
-		visitLock.Lock()
-		defer visitLock.Unlock()
-
-		// Don't visit, if already visited
-		if visited.Contains(cell.idx) {
-			return
-		}
-		visited.Add(cell.idx)
-
-		visitQueue = append(visitQueue, cell)
-		wg.Add(1)
-
-		go visitNext()
+	board := cell.board
+	if board.floodMarks == nil {
+		board.floodMarks = make([]uint32, board.NumCells())
 	}
 
-	dequeue := func() *Cell {
-		visitLock.Lock()
-		defer visitLock.Unlock()
-
-		cell := visitQueue[0]
-		visitQueue = visitQueue[1:]
-
-		return cell
+	// Bumping the generation unmarks every cell at once
+	board.floodGeneration++
+	if board.floodGeneration == 0 {
+		clear(board.floodMarks)
+		board.floodGeneration++
 	}
+	generation := board.floodGeneration
 
-	visitNext = func() {
-		defer wg.Done()
+	board.floodMarks[cell.idx] = generation
 
-		<-permitCh
-		defer func() {
-			permitCh <- struct{}{}
-		}()
+	visitQueue := []*Cell{cell}
+	for i := 0; i < len(visitQueue); i++ {
+		cell := visitQueue[i]
 
-		cell := dequeue()
 		visit(cell)
 
 		if cell.numMines == 0 {
-			for neighbor := range getNeighbors(cell) {
-				enqueue(neighbor)
+			for _, neighbor := range getNeighbors(cell) {
+				if board.floodMarks[neighbor.idx] != generation {
+					board.floodMarks[neighbor.idx] = generation
+					visitQueue = append(visitQueue, neighbor)
+				}
 			}
 		}
 	}
-
-	for i := 0; i < parallelism; i++ {
-		permitCh <- struct{}{}
-	}
-
-	enqueue(cell)
-	wg.Wait()
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/faiface/pixel/text"
 	"golang.org/x/image/font/basicfont"
 	"image"
+	"io"
 	"math"
 	"os"
 	"strings"
@@ -15,9 +16,10 @@ import (
 
 	_ "image/png"
 
+	"github.com/faiface/mainthread"
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/pixelgl"
-	"golang.org/x/image/colornames"
+	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 //go:embed assets/spritesheet.png
@@ -27,8 +29,14 @@ var cellSprites = map[CellState]*pixel.Sprite{}
 type GameMode int
 
 const (
+	// Mines are left as they are, so the first click may lose
 	Classic GameMode = iota
+	// The first cell clicked is always a zero (its neighbors are cleared of mines)
 	Win7
+	// The first cell clicked is never a mine
+	WinXP
+	// The first cell clicked is always a zero, as are its neighbors
+	Opening
 )
 
 type GameConfig struct {
@@ -38,6 +46,21 @@ type GameConfig struct {
 	MineDensity   float64
 	Mode          GameMode
 
+	// Shape of the board, if not rectangular (overrides Width and Height)
+	Shape *BoardShape
+
+	// Number of mines which may be revealed before the game is lost
+	Lives uint
+
+	// Whether right-clicks cycle through "?" marks, after flags
+	QuestionMarks bool
+
+	// Whether left-clicking a revealed number chords, as a middle click does
+	ChordOnClick bool
+	// Whether right-clicking a revealed number flags all its unrevealed
+	// neighbors, if they must all be mines
+	FlagChordOnRightClick bool
+
 	Seed int64
 
 	// Snapshot to load board configuration from
@@ -47,6 +70,13 @@ type GameConfig struct {
 
 	Director         Director
 	DirectorTickRate time.Duration
+	// Where to write the director's state each step, if it may be inspected
+	// (see InspectableDirector)
+	DirectorDump io.Writer
+	// Whether to check the director's beliefs against the true board after
+	// each change, panicking if they're wrong (see CheckableDirector). For
+	// testing directors.
+	CheckDirector bool
 
 	// Transparency of annotations when first displayed
 	AnnotationBaseAlpha float64
@@ -54,102 +84,162 @@ type GameConfig struct {
 	AnnotationDuration time.Duration
 
 	// Path to directory where final snapshots of boards should be saved
+	// (and where the editor saves boards; defaults to the current directory)
 	SavedSnapshotsDir string
+
+	// Whether to open boards in the editor, rather than playing them
+	Edit bool
+
+	KeyBindings KeyBindings
+	Colors      Colors
+
+	// Whether boards are played without a window (see boardConfig.Headless)
+	headless bool
 }
 
 func NewGameConfig() GameConfig {
 	return GameConfig{
-		Width:               30,
-		Height:              16,
-		NumMines:            99,
+		Width:               Expert.Width,
+		Height:              Expert.Height,
+		NumMines:            Expert.NumMines,
 		Fullscreen:          false,
 		MineDensity:         math.NaN(),
 		Mode:                Classic,
+		Lives:               1,
+		QuestionMarks:       true,
 		Director:            nil,
 		DirectorTickRate:    25 * time.Millisecond,
 		Snapshot:            nil,
 		LoadSnapshotFresh:   true,
 		AnnotationBaseAlpha: 0.5,
 		AnnotationDuration:  200 * time.Millisecond,
+		KeyBindings:         DefaultKeyBindings(),
+		Colors:              DefaultColors(),
 	}
 }
 
 func (config GameConfig) createBoard() *Board {
 	if config.Snapshot == nil {
+		if config.Shape != nil {
+			config.Width, config.Height = config.Shape.Width(), config.Shape.Height()
+		}
+
 		return createFilledBoard(boardConfig{
-			Width:            config.Width,
-			Height:           config.Height,
-			NumMines:         config.NumMines,
-			Mode:             config.Mode,
-			Seed:             config.Seed,
-			Director:         config.Director,
-			DirectorTickRate: config.DirectorTickRate,
-			OnGameEnd:        config.onGameEnd,
+			Width:                 config.Width,
+			Height:                config.Height,
+			NumMines:              config.NumMines,
+			NumLives:              config.Lives,
+			Mode:                  config.Mode,
+			QuestionMarks:         config.QuestionMarks,
+			ChordOnClick:          config.ChordOnClick,
+			FlagChordOnRightClick: config.FlagChordOnRightClick,
+			Shape:                 config.Shape,
+			Seed:                  config.Seed,
+			Director:              config.Director,
+			DirectorTickRate:      config.DirectorTickRate,
+			DirectorDump:          config.DirectorDump,
+			CheckDirector:         config.CheckDirector,
+			Headless:              config.headless,
+			OnGameEnd:             config.onGameEnd,
 		})
 	} else {
-		return config.Snapshot.CreateBoard(
-			boardConfig{
-				Mode:             config.Mode,
-				Director:         config.Director,
-				DirectorTickRate: config.DirectorTickRate,
-				OnGameEnd:        config.onGameEnd,
-			},
-			config.LoadSnapshotFresh,
-		)
+		return config.loadSnapshot(config.Snapshot, config.LoadSnapshotFresh)
 	}
 }
 
-func (config GameConfig) onGameEnd(board *Board) {
-	config.saveSnapshot(board)
+func (config GameConfig) loadSnapshot(snapshot *BoardSnapshot, fresh bool) *Board {
+	return snapshot.CreateBoard(
+		boardConfig{
+			Mode:                  config.Mode,
+			NumLives:              config.Lives,
+			QuestionMarks:         config.QuestionMarks,
+			ChordOnClick:          config.ChordOnClick,
+			FlagChordOnRightClick: config.FlagChordOnRightClick,
+			Director:              config.Director,
+			DirectorTickRate:      config.DirectorTickRate,
+			DirectorDump:          config.DirectorDump,
+			CheckDirector:         config.CheckDirector,
+			Headless:              config.headless,
+			OnGameEnd:             config.onGameEnd,
+		},
+		fresh,
+	)
+}
+
+// applyMineDensity calculates NumMines from MineDensity, if it was specified
+func (config *GameConfig) applyMineDensity() {
+	if !math.IsNaN(config.MineDensity) {
+		numCells := config.Width * config.Height
+		if config.Shape != nil {
+			numCells = config.Shape.NumCells()
+		}
+		config.NumMines = uint(float64(numCells) * config.MineDensity)
+	}
 }
 
-func (config GameConfig) saveSnapshot(board *Board) {
+func (config GameConfig) onGameEnd(board *Board) {
 	if config.SavedSnapshotsDir != "" {
-		stat, err := os.Stat(config.SavedSnapshotsDir)
-		if err != nil {
-			if os.IsNotExist(err) {
-				if err := os.MkdirAll(config.SavedSnapshotsDir, 0777); err != nil {
-					fmt.Println(err)
-					return
-				}
-			} else {
+		config.saveSnapshot(board, config.SavedSnapshotsDir)
+	}
+}
+
+// saveSnapshot writes a snapshot of the board into dir, returning the path of
+// the written file, or an empty string on failure
+func (config GameConfig) saveSnapshot(board *Board, dir string) string {
+	return config.writeSnapshot(board.snapshot(), board.state, dir)
+}
+
+// writeSnapshot writes the snapshot into dir, named after the state of its
+// board, returning the path of the written file, or an empty string on failure
+func (config GameConfig) writeSnapshot(snapshot *BoardSnapshot, state BoardState, dir string) string {
+	stat, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if err := os.MkdirAll(dir, 0777); err != nil {
 				fmt.Println(err)
-				return
+				return ""
 			}
-		} else if !stat.Mode().IsDir() {
-			fmt.Printf("%s is not a directory; cannot save snapshots to it.", config.SavedSnapshotsDir)
-			return
+		} else {
+			fmt.Println(err)
+			return ""
 		}
+	} else if !stat.Mode().IsDir() {
+		fmt.Printf("%s is not a directory; cannot save snapshots to it.", dir)
+		return ""
+	}
 
-		filename := config.generateReplayFilename(board, time.Now())
-		path := strings.Join([]string{config.SavedSnapshotsDir, filename}, string(os.PathSeparator))
+	filename := config.generateReplayFilename(state, time.Now())
+	path := strings.Join([]string{dir, filename}, string(os.PathSeparator))
 
-		// TODO: prevent duplicate filenames
-		file, err := os.Create(path)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
+	// TODO: prevent duplicate filenames
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Println(err)
+		return ""
+	}
+	defer file.Close()
 
-		snapshot := board.snapshot()
-		if _, err := file.WriteString(snapshot.Serialize()); err != nil {
-			fmt.Println(err)
-			return
-		}
+	if _, err := file.WriteString(snapshot.Serialize()); err != nil {
+		fmt.Println(err)
+		return ""
 	}
+
+	return path
 }
 
-func (config GameConfig) generateReplayFilename(board *Board, t time.Time) string {
+func (config GameConfig) generateReplayFilename(state BoardState, t time.Time) string {
 	filenameBuilder := strings.Builder{}
 
 	filenameBuilder.WriteString(t.Format("20060102_150405_"))
 
 	var stateStr string
-	switch board.state {
+	switch state {
 	case Won:
 		stateStr = "win"
 	case Lost:
 		stateStr = "loss"
+	case Editing:
+		stateStr = "edit"
 	default:
 		stateStr = "other"
 	}
@@ -161,8 +251,8 @@ func (config GameConfig) generateReplayFilename(board *Board, t time.Time) strin
 }
 
 func Run(config GameConfig) {
-	headerHeight := uint(50)
-	minWindowWith := float64(200)
+	headerHeight := uint(70)
+	minWindowWith := float64(280)
 
 	spritesheet := loadSpritesheet()
 	windowIconImageData := spritesheet.Image().SubImage(image.Rectangle{
@@ -197,9 +287,7 @@ func Run(config GameConfig) {
 		config.Height = uint((bounds.H() - float64(headerHeight)) / cellWidth)
 	}
 
-	if !math.IsNaN(config.MineDensity) {
-		config.NumMines = uint(float64(config.Width*config.Height) * config.MineDensity)
-	}
+	config.applyMineDensity()
 
 	batch := pixel.NewBatch(&pixel.TrianglesData{}, spritesheet)
 
@@ -207,18 +295,36 @@ func Run(config GameConfig) {
 
 	basicAtlas := text.NewAtlas(basicfont.Face7x13, text.ASCII)
 	var scoreText *text.Text
+	var saveText *text.Text
 	var cellPosText *text.Text
+	var presetText *text.Text
+	var statsText *text.Text
+	var presetMenuTexts []*text.Text
+	var isPresetMenuOpen bool
 	var hoveredCell *Cell
+	// Whether the cells related to the hovered cell are highlighted, and
+	// those of the cell last inspected
+	var isInspecting bool
+	var inspectedCell *Cell
+	var inspectedCells []*Cell
 
 	var board *Board
-	_resetBoard := func(paused bool) {
+	showBoard := func(newBoard *Board, paused bool) {
 		batch.Clear()
 
-		board = config.createBoard()
-		if paused {
-			board.TogglePaused()
+		if board != nil {
+			board.stop()
 		}
-		board.startGame()
+
+		board = newBoard
+		board.do(func() {
+			if board.state != Editing {
+				if paused {
+					board.state = Paused
+				}
+				board.startGame()
+			}
+		})
 
 		win.SetBounds(
 			pixel.R(
@@ -233,10 +339,33 @@ func Run(config GameConfig) {
 		boardTopLeft = topLeft.Sub(pixel.V(0, float64(headerHeight)))
 
 		scoreText = text.New(topLeft.Add(pixel.V(20, -30)), basicAtlas)
-		scoreText.Color = colornames.Black
+		scoreText.Color = config.Colors.Text
+
+		saveText = text.New(topLeft.Add(pixel.V(20, -30)), basicAtlas)
+		saveText.Color = config.Colors.SaveButton
 
 		cellPosText = text.New(topRight.Add(pixel.V(-60, -30)), basicAtlas)
-		cellPosText.Color = colornames.Darkcyan
+		cellPosText.Color = config.Colors.CellPosition
+
+		presetText = text.New(topLeft.Add(pixel.V(20, -55)), basicAtlas)
+		presetText.Color = config.Colors.SaveButton
+
+		statsText = text.New(topLeft.Add(pixel.V(20, -55)), basicAtlas)
+		statsText.Color = config.Colors.Text
+
+		// The preset menu drops down below its button, over the board
+		presetMenuTexts = make([]*text.Text, len(Presets))
+		for i := range Presets {
+			presetMenuTexts[i] = text.New(presetText.Orig.Sub(pixel.V(0, float64(16*(i+1)))), basicAtlas)
+		}
+		isPresetMenuOpen = false
+	}
+	_resetBoard := func(paused bool) {
+		newBoard := config.createBoard()
+		if config.Edit {
+			newBoard.do(newBoard.edit)
+		}
+		showBoard(newBoard, paused)
 	}
 	resetBoard := func() {
 		_resetBoard(false)
@@ -244,6 +373,84 @@ func Run(config GameConfig) {
 	resetBoardPaused := func() {
 		_resetBoard(true)
 	}
+	// Open the current board, as it stands, in the editor
+	editBoard := func() {
+		var snapshot *BoardSnapshot
+		board.do(func() {
+			snapshot = board.snapshot()
+		})
+
+		newBoard := config.loadSnapshot(snapshot, false)
+		newBoard.do(newBoard.edit)
+		showBoard(newBoard, false)
+	}
+	// Start a new game with boards sized as the preset
+	switchPreset := func(preset Preset) {
+		config.ApplyPreset(preset)
+		config.Seed = board.Rand().Int63()
+		resetBoard()
+	}
+	// Copy the current board's code to the clipboard, to share it
+	copyBoardCode := func() {
+		var code BoardCode
+		board.do(func() {
+			code = board.code(false)
+		})
+
+		mainthread.Call(func() {
+			glfw.SetClipboardString(code.String())
+		})
+		fmt.Printf("Copied board code to clipboard: %s\n", code)
+	}
+	// Play the board whose code is in the clipboard
+	pasteBoardCode := func() {
+		var clipboard string
+		mainthread.Call(func() {
+			clipboard = glfw.GetClipboardString()
+		})
+
+		code, err := ParseBoardCode(clipboard)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		config.ApplyBoardCode(code)
+		resetBoard()
+	}
+	// Print the director's state, and toggle highlighting the cells related
+	// to the hovered cell
+	inspectDirector := func() {
+		var isInspectable bool
+		board.do(func() {
+			var inspectable InspectableDirector
+			if inspectable, isInspectable = board.director.(InspectableDirector); isInspectable {
+				inspectable.Dump(os.Stdout)
+			}
+		})
+		if !isInspectable {
+			fmt.Println("The director can't be inspected")
+			return
+		}
+
+		isInspecting = !isInspecting
+		inspectedCell, inspectedCells = nil, nil
+	}
+	saveEditedBoard := func() {
+		dir := config.SavedSnapshotsDir
+		if dir == "" {
+			dir = "."
+		}
+
+		var path string
+		board.do(func() {
+			path = config.saveSnapshot(board, dir)
+		})
+
+		if path != "" {
+			fmt.Printf("Saved board snapshot to %s\n", path)
+		}
+	}
 
 	resetBoard()
 
@@ -261,112 +468,213 @@ func Run(config GameConfig) {
 		ticker.Reset(time.Nanosecond)
 	}
 
-	bgColor := colornames.Gainsboro
+	bgColor := config.Colors.Background
+	keys := config.KeyBindings
+	// State of the board as of the last frame, which input is handled against
+	var state BoardState
+	// Whether the left button was pressed on the board, and is to click as it's
+	// released, unless it chords in the meantime
+	isClicking := false
 	for !win.Closed() {
+		// Input is polled by win.Update each frame, so it's handled only once
+		// each frame is drawn
 		select {
 		case <-second:
 			win.SetTitle(fmt.Sprintf("%s | FPS: %d", cfg.Title, frames))
 			frames = 0
+			continue
 		case <-ticker.C:
 			frames++
 
 			frameStart = time.Now()
 			win.Clear(bgColor)
 
-			scoreText.Clear()
-			scoreText.Color = colornames.Black
-
-			fmt.Fprintf(scoreText, "%03d", board.numMines-board.numFlags)
-			if !board.canPlay() {
-				var boardState string
-				if board.state == Won {
-					boardState = "WIN!"
-					scoreText.Color = colornames.Green
-				} else if board.state == Lost {
-					boardState = "LOSE :("
-					scoreText.Color = colornames.Red
+			mouseInsideWindow := win.MouseInsideWindow()
+			mousePosition := win.MousePosition()
+
+			// Read all board state from within its event loop, drawing to
+			// the window only afterward
+			var imd *imdraw.IMDraw
+			var isEditing bool
+			board.do(func() {
+				state = board.state
+				isEditing = board.state == Editing
+
+				scoreText.Clear()
+				scoreText.Color = config.Colors.Text
+
+				fmt.Fprintf(scoreText, "%03d", int(board.numMines)-int(board.numFlags))
+				if board.numLives > 1 {
+					fmt.Fprintf(scoreText, "   Lives: %d", board.NumLivesRemaining())
 				}
+				if !board.canPlay() {
+					var boardState string
+					if board.state == Won {
+						boardState = "WIN!"
+						scoreText.Color = config.Colors.Won
+					} else if board.state == Lost {
+						boardState = "LOSE :("
+						scoreText.Color = config.Colors.Lost
+					} else if isEditing {
+						boardState = "EDIT"
+					}
 
-				fmt.Fprintf(scoreText, "   %s", boardState)
-			}
-			scoreText.Draw(win, pixel.IM)
+					fmt.Fprintf(scoreText, "   %s", boardState)
+				}
 
-			if win.MouseInsideWindow() {
-				x, y := board.screenToGridCoords(win.MousePosition())
-				hoveredCell = board.CellAt(x, y)
-			} else {
-				hoveredCell = nil
-			}
+				saveText.Orig = pixel.V(scoreText.Bounds().Max.X+20, scoreText.Orig.Y)
+				saveText.Clear()
+				fmt.Fprint(saveText, "[Save]")
 
-			cellPosText.Clear()
-			if hoveredCell != nil {
-				fmt.Fprintf(cellPosText, "(%d, %d)", hoveredCell.x, hoveredCell.y)
-				cellPosText.Draw(win, pixel.IM)
-			}
+				presetText.Clear()
+				fmt.Fprintf(presetText, "[%s]", presetTitle(config.PresetName()))
 
-			for y, row := range board.cells {
-				rowStart := boardTopLeft.Add(pixel.V(cellWidth/2, -float64(cellWidth/2+cellWidth*y)))
+				// Show how efficiently the board was played, once the game ends
+				statsText.Orig = pixel.V(presetText.Bounds().Max.X+20, presetText.Orig.Y)
+				statsText.Clear()
+				if board.state == Won || board.state == Lost {
+					fmt.Fprintf(statsText, "IOE %.2f  3BV/s %.2f", board.stats.IOE, board.stats.BBBVPerSecond)
+				}
 
-				for x, cell := range row {
-					cellPos := rowStart.Add(pixel.V(float64(cellWidth*x), 0))
+				if mouseInsideWindow {
+					x, y := board.screenToGridCoords(mousePosition)
+					hoveredCell = board.CellAt(x, y)
+				} else {
+					hoveredCell = nil
+				}
+
+				cellPosText.Clear()
+				if hoveredCell != nil {
+					fmt.Fprintf(cellPosText, "(%d, %d)", hoveredCell.x, hoveredCell.y)
+				}
 
-					if cell.isDirty {
-						cell.sprite.Draw(batch, pixel.IM.Moved(cellPos))
-						(&board.cells[y][x]).isDirty = false
+				// Inspect the hovered cell each frame, as the director's state
+				// changes, but describe it only once it's first hovered
+				if inspectable, isInspectable := board.director.(InspectableDirector); isInspecting && isInspectable {
+					inspectedCells = inspectedCells[:0]
+					if hoveredCell != nil {
+						related, lines := inspectable.Inspect(CellView{hoveredCell})
+						for _, cell := range related {
+							inspectedCells = append(inspectedCells, cell.cell)
+						}
+
+						if hoveredCell != inspectedCell {
+							fmt.Printf("(%d, %d):\n", hoveredCell.x, hoveredCell.y)
+							for _, line := range lines {
+								fmt.Printf("  %s\n", line)
+							}
+						}
 					}
+					inspectedCell = hoveredCell
 				}
-			}
-			batch.Draw(win)
 
-			if board.directorAnnotations.Len() > 0 {
-				imd := imdraw.New(nil)
+				for y, row := range board.cells {
+					rowStart := boardTopLeft.Add(pixel.V(cellWidth/2, -float64(cellWidth/2+cellWidth*y)))
 
-				now := time.Now()
-				for i := 0; i < board.directorAnnotations.Len(); i++ {
-					annotation := board.directorAnnotations.At(i)
+					for x, cell := range row {
+						cellPos := rowStart.Add(pixel.V(float64(cellWidth*x), 0))
 
-					timeShown := now.Sub(annotation.firstShown)
-					isFromLatestFrame := annotation.frame == board.directorFrame
+						if cell.isDirty {
+							cell.sprite.Draw(batch, pixel.IM.Moved(cellPos))
+							(&board.cells[y][x]).isDirty = false
+						}
+					}
+				}
 
-					if timeShown > config.AnnotationDuration && !isFromLatestFrame {
-						board.directorAnnotations.PopFront()
-						continue
+				if board.directorAnnotations.Len() > 0 {
+					imd = imdraw.New(nil)
+
+					now := time.Now()
+					for i := 0; i < board.directorAnnotations.Len(); i++ {
+						annotation := board.directorAnnotations.At(i)
+
+						timeShown := now.Sub(annotation.firstShown)
+						isFromLatestFrame := annotation.frame == board.directorFrame
+
+						if timeShown > config.AnnotationDuration && !isFromLatestFrame {
+							board.directorAnnotations.PopFront()
+							continue
+						}
+
+						cell := annotation.cell
+						start := boardTopLeft.Add(
+							pixel.V(
+								float64(cellWidth*cell.x),
+								-float64(cellWidth*(cell.y+1)),
+							),
+						)
+						end := start.Add(pixel.V(cellWidth, cellWidth))
+						baseColor := config.Colors.annotation(annotation.Type)
+
+						alpha := config.AnnotationBaseAlpha
+						if !isFromLatestFrame {
+							progress := 1 - float64(timeShown)/float64(config.AnnotationDuration)
+							alphaMultiplier := InOutCubic(progress)
+							alpha *= alphaMultiplier
+						}
+
+						imd.Color = baseColor.Mul(pixel.Alpha(alpha))
+						imd.Push(start, end)
+						imd.Rectangle(0) // 0 = filled
 					}
+				}
+			})
+
+			scoreText.Draw(win, pixel.IM)
+			if isEditing {
+				saveText.Draw(win, pixel.IM)
+			}
+			if hoveredCell != nil {
+				cellPosText.Draw(win, pixel.IM)
+			}
 
-					cell := annotation.Cell
+			batch.Draw(win)
+			if imd != nil {
+				imd.Draw(win)
+			}
+			if isInspecting && len(inspectedCells) > 0 {
+				outlines := imdraw.New(nil)
+				outlines.Color = config.Colors.Inspect
+				for _, cell := range inspectedCells {
 					start := boardTopLeft.Add(
 						pixel.V(
 							float64(cellWidth*cell.x),
 							-float64(cellWidth*(cell.y+1)),
 						),
 					)
-					end := start.Add(pixel.V(cellWidth, cellWidth))
-					baseColor := pixel.Alpha(0)
-
-					switch annotation.Type {
-					case AnnotateClick:
-						baseColor = pixel.RGB(1, 0, 0)
-					case AnnotateRightClick:
-						baseColor = pixel.RGB(0, 0, 1)
-					case AnnotateMiddleClick:
-						baseColor = pixel.RGB(0, 1, 0)
-					case AnnotateHighlightYellow:
-						baseColor = pixel.RGB(1, 1, 0)
-					}
+					outlines.Push(start.Add(pixel.V(1, 1)), start.Add(pixel.V(cellWidth-1, cellWidth-1)))
+					outlines.Rectangle(2)
+				}
+				outlines.Draw(win)
+			}
 
-					alpha := config.AnnotationBaseAlpha
-					if !isFromLatestFrame {
-						progress := 1 - float64(timeShown)/float64(config.AnnotationDuration)
-						alphaMultiplier := InOutCubic(progress)
-						alpha *= alphaMultiplier
+			presetText.Draw(win, pixel.IM)
+			statsText.Draw(win, pixel.IM)
+			if isPresetMenuOpen {
+				menuBounds := presetMenuTexts[0].Bounds()
+				for i, preset := range Presets {
+					option := presetMenuTexts[i]
+					option.Clear()
+					option.Color = config.Colors.Text
+					if mouseInsideWindow && option.Bounds().Contains(mousePosition) {
+						option.Color = config.Colors.SaveButton
 					}
-
-					imd.Color = baseColor.Mul(pixel.Alpha(alpha))
-					imd.Push(start, end)
-					imd.Rectangle(0) // 0 = filled
+					fmt.Fprintf(option, "%-12s %dx%d/%d", preset.Title(), preset.Width, preset.Height, preset.NumMines)
+					menuBounds = menuBounds.Union(option.Bounds())
 				}
 
-				imd.Draw(win)
+				menu := imdraw.New(nil)
+				menu.Color = config.Colors.Background
+				menu.Push(menuBounds.Min.Sub(pixel.V(4, 4)), menuBounds.Max.Add(pixel.V(4, 4)))
+				menu.Rectangle(0)
+				menu.Color = config.Colors.Text
+				menu.Push(menuBounds.Min.Sub(pixel.V(4, 4)), menuBounds.Max.Add(pixel.V(4, 4)))
+				menu.Rectangle(1)
+				menu.Draw(win)
+
+				for _, option := range presetMenuTexts {
+					option.Draw(win, pixel.IM)
+				}
 			}
 			win.Update()
 
@@ -376,18 +684,98 @@ func Run(config GameConfig) {
 				frameDelay = time.Nanosecond
 			}
 			ticker.Reset(frameDelay)
-		default:
 		}
 
-		if board.canPlay() {
+		// A left press handled by anything but the board doesn't click
+		if win.JustPressed(pixelgl.MouseButtonLeft) {
+			isClicking = false
+		}
+
+		// Copy or paste board codes with C and V, in any state
+		if keys.justPressed(win, KeyCopyCode) {
+			copyBoardCode()
+		}
+		if keys.justPressed(win, KeyPasteCode) {
+			pasteBoardCode()
+			requestFrame()
+			continue
+		}
+
+		// Inspect the director with I, in any state
+		if keys.justPressed(win, KeyInspect) {
+			inspectDirector()
+			requestFrame()
+		}
+
+		// Switch preset from the menu, and close it with any click
+		if isPresetMenuOpen {
+			if win.JustPressed(pixelgl.MouseButtonLeft) || win.JustPressed(pixelgl.MouseButtonRight) || win.JustPressed(pixelgl.MouseButtonMiddle) {
+				isPresetMenuOpen = false
+				if win.JustPressed(pixelgl.MouseButtonLeft) {
+					for i, option := range presetMenuTexts {
+						if option.Bounds().Contains(win.MousePosition()) {
+							switchPreset(Presets[i])
+						}
+					}
+				}
+				requestFrame()
+			}
+			continue
+		}
+		if win.JustPressed(pixelgl.MouseButtonLeft) && presetText.Bounds().Contains(win.MousePosition()) {
+			isPresetMenuOpen = true
+			requestFrame()
+			continue
+		}
+
+		if state == Editing {
+			// Play the edited board with Enter
+			if keys.justPressed(win, KeyPlayEdited) {
+				board.do(board.finishEditing)
+				requestFrame()
+				continue
+			}
+
+			// Save the edited board with S, or by clicking the save button
+			if keys.justPressed(win, KeySave) ||
+				(win.JustPressed(pixelgl.MouseButtonLeft) && saveText.Bounds().Contains(win.MousePosition())) {
+				saveEditedBoard()
+			}
+
+			if hoveredCell != nil {
+				// Left click toggles mines, Shift + left click toggles revealed,
+				// and right click toggles flags
+				if win.JustPressed(pixelgl.MouseButtonLeft) {
+					toggle := board.toggleMine
+					if keys.pressed(win, KeyToggleRevealed) {
+						toggle = board.toggleRevealed
+					}
+
+					board.do(func() {
+						toggle(hoveredCell)
+					})
+					requestFrame()
+				}
+				if win.JustPressed(pixelgl.MouseButtonRight) {
+					board.do(func() {
+						board.toggleFlagged(hoveredCell)
+					})
+					requestFrame()
+				}
+			}
+
+			continue
+		}
+
+		if state == Ongoing || state == Paused {
 			// Pause with Space
-			if win.JustPressed(pixelgl.KeySpace) {
+			if keys.justPressed(win, KeyPause) {
 				board.TogglePaused()
 				requestFrame()
 			}
 
 			// Perform single step while paused with Right Arrow
-			if board.state == Paused && (win.JustPressed(pixelgl.KeyRight) || win.Repeated(pixelgl.KeyRight)) {
+			if state == Paused && (keys.justPressed(win, KeyStep) || keys.repeated(win, KeyStep)) {
 				board.TogglePaused()
 				board.RequestDirectorAct()
 				board.TogglePaused()
@@ -395,15 +783,21 @@ func Run(config GameConfig) {
 			}
 		} else {
 			// Start a new game with Enter
-			if win.JustPressed(pixelgl.KeyEnter) {
-				config.Seed = board.rand.Int63()
+			if keys.justPressed(win, KeyNewGame) {
+				config.Seed = board.Rand().Int63()
 				resetBoard()
 				requestFrame()
 			}
 
+			// Open the finished board in the editor with E
+			if keys.justPressed(win, KeyEdit) {
+				editBoard()
+				requestFrame()
+			}
+
 			// Start a new, paused game with Space or Right Arrow
-			if win.JustPressed(pixelgl.KeySpace) || win.JustPressed(pixelgl.KeyRight) {
-				config.Seed = board.rand.Int63()
+			if keys.justPressed(win, KeyNewGamePaused) {
+				config.Seed = board.Rand().Int63()
 				resetBoardPaused()
 				requestFrame()
 			}
@@ -411,20 +805,28 @@ func Run(config GameConfig) {
 			continue
 		}
 
-		if win.JustPressed(pixelgl.MouseButtonLeft) || win.JustPressed(pixelgl.MouseButtonRight) || win.JustPressed(pixelgl.MouseButtonMiddle) {
-			if hoveredCell != nil {
-				if win.JustPressed(pixelgl.MouseButtonLeft) {
-					hoveredCell.click()
-					requestFrame()
-				}
-				if win.JustPressed(pixelgl.MouseButtonRight) {
-					hoveredCell.rightClick()
-					requestFrame()
-				}
-				if win.JustPressed(pixelgl.MouseButtonMiddle) {
-					hoveredCell.middleClick()
-					requestFrame()
-				}
+		if hoveredCell != nil {
+			leftPressed, rightPressed := win.JustPressed(pixelgl.MouseButtonLeft), win.JustPressed(pixelgl.MouseButtonRight)
+
+			// Left clicks act on release, so a left press that becomes part of
+			// a chord is neither dispatched nor counted as an input
+			switch {
+			// Pressing left and right together chords, as a middle click does
+			case win.JustPressed(pixelgl.MouseButtonMiddle),
+				leftPressed && win.Pressed(pixelgl.MouseButtonRight),
+				rightPressed && win.Pressed(pixelgl.MouseButtonLeft):
+				isClicking = false
+				board.do(hoveredCell.playerMiddleClick)
+				requestFrame()
+			case leftPressed:
+				isClicking = true
+			case rightPressed:
+				board.do(hoveredCell.playerRightClick)
+				requestFrame()
+			case isClicking && win.JustReleased(pixelgl.MouseButtonLeft):
+				isClicking = false
+				board.do(hoveredCell.playerClick)
+				requestFrame()
 			}
 		}
 	}
@@ -440,12 +842,16 @@ func InOutCubic(t float64) float64 {
 	}
 }
 
-func loadSpritesheet() *pixel.PictureData {
+func decodeSpritesheet() image.Image {
 	img, _, err := image.Decode(bytes.NewReader(spritesheetPNG))
 	if err != nil {
 		panic(err)
 	}
-	spritesheet := pixel.PictureDataFromImage(img)
+	return img
+}
+
+func loadSpritesheet() *pixel.PictureData {
+	spritesheet := pixel.PictureDataFromImage(decodeSpritesheet())
 
 	x1, x2 := float64(0), float64(cellWidth)
 	y2 := spritesheet.Bounds().Max.Y
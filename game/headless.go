@@ -0,0 +1,98 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// Number of steps in a row a director may take without changing the board,
+// before it's considered stuck
+const maxIdleSteps = 10
+
+// GameResult summarizes a game played by PlayHeadless
+type GameResult struct {
+	// Won, Lost, or Ongoing if the director gave up before the game ended
+	State BoardState
+	// Canonical name of the preset the board matches (see PresetName)
+	Preset string
+
+	// Number of director steps taken
+	NumSteps int
+	// Number of non-mine cells revealed, out of NumSafeCells
+	NumRevealed  uint
+	NumSafeCells uint
+	NumStrikes   uint
+	// Inputs the director played with, and how efficiently (only calculated
+	// if the game ended)
+	Stats GameStats
+
+	Duration time.Duration
+}
+
+// PlayHeadless plays a whole game with the config's director, without opening
+// a window, and returns its result
+func PlayHeadless(config GameConfig) (GameResult, error) {
+	if config.Director == nil {
+		return GameResult{}, fmt.Errorf("a director is required to play headlessly")
+	}
+
+	config.headless = true
+	config.applyMineDensity()
+
+	board := config.createBoard()
+	if board == nil {
+		return GameResult{}, fmt.Errorf("unable to create board")
+	}
+	defer board.stop()
+
+	var result GameResult
+	board.do(func() {
+		start := time.Now()
+		board.playHeadless(nil, func() {
+			result.NumSteps++
+		})
+
+		result.Duration = time.Since(start)
+		result.State = board.state
+		result.Preset = board.presetName()
+		result.NumSafeCells = uint(len(board.CellList())) - board.numMines
+		result.NumRevealed = result.NumSafeCells - uint(len(board.remainingCells))
+		result.NumStrikes = board.numStrikes
+		result.Stats = board.stats
+	})
+
+	return result, nil
+}
+
+// playHeadless starts the game, then steps the director until the game ends,
+// or the director gives up. afterStart is called once the game has started,
+// and afterStep after each step, if they're not nil.
+func (board *Board) playHeadless(afterStart func(), afterStep func()) {
+	board.startGame()
+	if afterStart != nil {
+		afterStart()
+	}
+
+	numIdleSteps := 0
+	for board.canPlay() {
+		if board.directorStep() == 0 {
+			// The director has given up
+			break
+		}
+
+		// Directors which stop changing the board would otherwise play forever
+		if len(board.directorCellChanges) == 0 {
+			numIdleSteps++
+		} else {
+			numIdleSteps = 0
+		}
+
+		if afterStep != nil {
+			afterStep()
+		}
+
+		if numIdleSteps >= maxIdleSteps {
+			break
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"fmt"
+	"github.com/they4kman/gosweep/minegen"
+	"time"
+)
+
+// HugeGameResult summarizes a game played by PlayHugeGame
+type HugeGameResult struct {
+	Width, Height uint
+	NumCells      uint
+	NumMines      uint
+
+	// Won or Lost
+	State       BoardState
+	NumRevealed uint
+	NumFlagged  uint
+	NumStrikes  uint
+	NumGuesses  uint
+
+	// Time taken to place the mines
+	GenerateDuration time.Duration
+	// Time taken to play the game, from first click to end
+	SolveDuration time.Duration
+
+	// Path the final board was saved to, if the config asked for it
+	SnapshotPath string
+}
+
+// PlayHugeGame generates a board from the config and plays it to the end,
+// without opening a window. Boards are stored compactly, so they may have tens
+// of millions of cells. The config's director is not used; the game is played
+// by a simple built-in solver instead (see bitboardSolver).
+func PlayHugeGame(config GameConfig) (HugeGameResult, error) {
+	if config.Snapshot != nil {
+		return HugeGameResult{}, fmt.Errorf("snapshots cannot be played as huge games")
+	}
+
+	if config.Shape != nil {
+		config.Width, config.Height = config.Shape.Width(), config.Shape.Height()
+	}
+	config.applyMineDensity()
+
+	numCells := config.Width * config.Height
+	if numCells == 0 || numCells > 1<<32-1 {
+		return HugeGameResult{}, fmt.Errorf("huge boards must have between 1 and %d cells", uint32(1<<32-1))
+	}
+
+	start := time.Now()
+	board := newBitboard(boardConfig{
+		Width:    config.Width,
+		Height:   config.Height,
+		NumLives: config.Lives,
+		Mode:     config.Mode,
+		Shape:    config.Shape,
+		Seed:     config.Seed,
+	})
+	board.fillMines(config.NumMines)
+	generated := time.Now()
+
+	solver := newBitboardSolver(board)
+	solver.solve()
+	solved := time.Now()
+
+	result := HugeGameResult{
+		Width:    config.Width,
+		Height:   config.Height,
+		NumCells: uint(board.numCells) - board.numVoids,
+		NumMines: board.numMines,
+
+		State:      board.state,
+		NumFlagged: board.numFlags,
+		NumStrikes: board.numStrikes,
+		NumGuesses: solver.numGuesses,
+
+		GenerateDuration: generated.Sub(start),
+		SolveDuration:    solved.Sub(generated),
+	}
+	result.NumRevealed = result.NumCells - board.numMines - board.numRemaining
+
+	if config.SavedSnapshotsDir != "" {
+		snapshot := &BoardSnapshot{
+			Seed:            config.Seed,
+			Mode:            gameModeName(config.Mode),
+			Lives:           board.numLives,
+			Preset:          config.PresetName(),
+			Generator:       minegen.Version,
+			SerializedBoard: board.serialize(),
+		}
+		result.SnapshotPath = config.writeSnapshot(snapshot, board.state, config.SavedSnapshotsDir)
+	}
+
+	return result, nil
+}
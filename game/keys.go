@@ -0,0 +1,107 @@
+package game
+
+import (
+	"fmt"
+	"github.com/faiface/pixel/pixelgl"
+	"strings"
+)
+
+// KeyAction is something done with the press of a key (or mouse button)
+type KeyAction string
+
+const (
+	// Pause or resume the director
+	KeyPause KeyAction = "pause"
+	// Perform a single director step, while paused
+	KeyStep KeyAction = "step"
+	// Start a new game, once the current one has ended
+	KeyNewGame KeyAction = "new-game"
+	// Start a new, paused game, once the current one has ended
+	KeyNewGamePaused KeyAction = "new-game-paused"
+	// Open the finished board in the editor
+	KeyEdit KeyAction = "edit"
+	// Save the board being edited
+	KeySave KeyAction = "save"
+	// Play the board being edited
+	KeyPlayEdited KeyAction = "play-edited"
+	// Toggle whether a cell is revealed in the editor, when held while clicking
+	KeyToggleRevealed KeyAction = "toggle-revealed"
+	// Copy the code of the current board to the clipboard
+	KeyCopyCode KeyAction = "copy-code"
+	// Play the board whose code is in the clipboard
+	KeyPasteCode KeyAction = "paste-code"
+	// Print the director's state, and toggle highlighting what it knows of the
+	// hovered cell (see InspectableDirector)
+	KeyInspect KeyAction = "inspect"
+)
+
+var KeyActions = []KeyAction{
+	KeyPause,
+	KeyStep,
+	KeyNewGame,
+	KeyNewGamePaused,
+	KeyEdit,
+	KeySave,
+	KeyPlayEdited,
+	KeyToggleRevealed,
+	KeyCopyCode,
+	KeyPasteCode,
+	KeyInspect,
+}
+
+// KeyBindings maps each action to the buttons which perform it
+type KeyBindings map[KeyAction][]pixelgl.Button
+
+func DefaultKeyBindings() KeyBindings {
+	return KeyBindings{
+		KeyPause:          {pixelgl.KeySpace},
+		KeyStep:           {pixelgl.KeyRight},
+		KeyNewGame:        {pixelgl.KeyEnter},
+		KeyNewGamePaused:  {pixelgl.KeySpace, pixelgl.KeyRight},
+		KeyEdit:           {pixelgl.KeyE},
+		KeySave:           {pixelgl.KeyS},
+		KeyPlayEdited:     {pixelgl.KeyEnter},
+		KeyToggleRevealed: {pixelgl.KeyLeftShift, pixelgl.KeyRightShift},
+		KeyCopyCode:       {pixelgl.KeyC},
+		KeyPasteCode:      {pixelgl.KeyV},
+		KeyInspect:        {pixelgl.KeyI},
+	}
+}
+
+// ParseButton returns the key or mouse button with the name (e.g. "Space",
+// "E", "MouseButtonMiddle"), ignoring case
+func ParseButton(name string) (pixelgl.Button, error) {
+	for button := pixelgl.Button(0); button <= pixelgl.KeyLast; button++ {
+		if buttonName := button.String(); buttonName != "Invalid" && strings.EqualFold(buttonName, name) {
+			return button, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key %q", name)
+}
+
+func (bindings KeyBindings) justPressed(win *pixelgl.Window, action KeyAction) bool {
+	for _, button := range bindings[action] {
+		if win.JustPressed(button) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bindings KeyBindings) pressed(win *pixelgl.Window, action KeyAction) bool {
+	for _, button := range bindings[action] {
+		if win.Pressed(button) {
+			return true
+		}
+	}
+	return false
+}
+
+func (bindings KeyBindings) repeated(win *pixelgl.Window, action KeyAction) bool {
+	for _, button := range bindings[action] {
+		if win.Repeated(button) {
+			return true
+		}
+	}
+	return false
+}
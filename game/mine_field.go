@@ -0,0 +1,99 @@
+package game
+
+import "github.com/they4kman/gosweep/minegen"
+
+// boardField lets minegen place a Board's mines
+type boardField struct {
+	board *Board
+}
+
+var _ minegen.Field = boardField{}
+
+func (field boardField) NumCells() uint32 {
+	return uint32(field.board.NumCells())
+}
+
+func (field boardField) NumPlayable() uint32 {
+	return uint32(len(field.board.CellList()))
+}
+
+func (field boardField) NumMines() uint32 {
+	return uint32(field.board.numMines)
+}
+
+func (field boardField) IsVoid(idx uint32) bool {
+	return field.cell(idx).isVoid
+}
+
+func (field boardField) IsMine(idx uint32) bool {
+	return field.cell(idx).isMine
+}
+
+func (field boardField) SetMine(idx uint32, isMine bool) {
+	field.board.setMine(field.cell(idx), isMine)
+}
+
+func (field boardField) cell(idx uint32) *Cell {
+	board := field.board
+	return &board.cells[uint(idx)/board.width][uint(idx)%board.width]
+}
+
+// bitboardField lets minegen place a bitboard's mines. Surrounding mine counts
+// are only updated if countMines is set; otherwise, they're left to be counted
+// once all mines are placed.
+type bitboardField struct {
+	board      *bitboard
+	countMines bool
+}
+
+var _ minegen.Field = bitboardField{}
+
+func (field bitboardField) NumCells() uint32 {
+	return field.board.numCells
+}
+
+func (field bitboardField) NumPlayable() uint32 {
+	return field.board.numCells - uint32(field.board.numVoids)
+}
+
+func (field bitboardField) NumMines() uint32 {
+	return uint32(field.board.numMines)
+}
+
+func (field bitboardField) IsVoid(idx uint32) bool {
+	return field.board.isVoid(idx)
+}
+
+func (field bitboardField) IsMine(idx uint32) bool {
+	return field.board.mines.has(idx)
+}
+
+func (field bitboardField) SetMine(idx uint32, isMine bool) {
+	board := field.board
+	if field.countMines {
+		board.setMine(idx, isMine)
+	} else if isMine && !board.mines.has(idx) {
+		board.mines.add(idx)
+		board.numMines++
+	} else if !isMine && board.mines.has(idx) {
+		board.mines.remove(idx)
+		board.numMines--
+	}
+}
+
+// squareAround returns the indexes of the non-void cells in the square of
+// cells within radius of (x, y), clipped to the board
+func squareAround(width, height, x, y, radius uint32, isVoid func(idx uint32) bool) []uint32 {
+	minX, maxX := x-min(x, radius), min(x+radius, width-1)
+	minY, maxY := y-min(y, radius), min(y+radius, height-1)
+
+	square := make([]uint32, 0, (2*radius+1)*(2*radius+1))
+	for squareY := minY; squareY <= maxY; squareY++ {
+		for squareX := minX; squareX <= maxX; squareX++ {
+			if idx := squareY*width + squareX; !isVoid(idx) {
+				square = append(square, idx)
+			}
+		}
+	}
+	return square
+}
@@ -0,0 +1,120 @@
+package game
+
+// Cells and their neighbors are indexed into flat tables the first time they're
+// traversed, so traversals don't allocate. The tables are rebuilt if the
+// board's shape changes (i.e. cells are made void).
+//
+// For each cell, neighborTable holds the cell itself, followed by its
+// non-void neighbors, between neighborOffsets[cell.idx] and
+// neighborOffsets[cell.idx+1]. Void cells have no entries at all.
+
+// Maximum number of neighbors a cell may have, plus the cell itself
+const maxSelfNeighbors = 9
+
+func (board *Board) indexCells() {
+	if board.isIndexed {
+		return
+	}
+
+	numCells := board.NumCells()
+	board.cellList = make([]*Cell, 0, numCells)
+	board.neighborOffsets = make([]uint32, numCells+1)
+	board.neighborTable = make([]*Cell, 0, numCells*maxSelfNeighbors)
+
+	for y := uint(0); y < board.height; y++ {
+		for x := uint(0); x < board.width; x++ {
+			cell := board.CellAt(x, y)
+			board.neighborOffsets[cell.idx] = uint32(len(board.neighborTable))
+
+			if !cell.isVoid {
+				board.cellList = append(board.cellList, cell)
+				board.neighborTable = append(board.neighborTable, cell)
+				board.neighborTable = cell.appendNeighbors(board.neighborTable)
+			}
+		}
+	}
+	board.neighborOffsets[numCells] = uint32(len(board.neighborTable))
+
+	board.isIndexed = true
+}
+
+// appendNeighbors appends the cell's non-void neighbors to cells
+func (cell *Cell) appendNeighbors(cells []*Cell) []*Cell {
+	board := cell.board
+
+	add := func(neighbor *Cell) {
+		if !neighbor.isVoid {
+			cells = append(cells, neighbor)
+		}
+	}
+
+	isAtTopBorder := cell.y < 1
+	isAtBottomBorder := cell.y >= board.height-1
+
+	if cell.x >= 1 {
+		add(board.CellAt(cell.x-1, cell.y))
+
+		if !isAtTopBorder {
+			add(board.CellAt(cell.x-1, cell.y-1))
+		}
+		if !isAtBottomBorder {
+			add(board.CellAt(cell.x-1, cell.y+1))
+		}
+	}
+
+	if cell.x < board.width-1 {
+		add(board.CellAt(cell.x+1, cell.y))
+
+		if !isAtTopBorder {
+			add(board.CellAt(cell.x+1, cell.y-1))
+		}
+		if !isAtBottomBorder {
+			add(board.CellAt(cell.x+1, cell.y+1))
+		}
+	}
+
+	if !isAtTopBorder {
+		add(board.CellAt(cell.x, cell.y-1))
+	}
+	if !isAtBottomBorder {
+		add(board.CellAt(cell.x, cell.y+1))
+	}
+
+	return cells
+}
+
+// CellList returns all non-void cells, in order from left-to-right, top-to-bottom.
+// The returned slice is shared, and must not be modified.
+func (board *Board) CellList() []*Cell {
+	board.indexCells()
+	return board.cellList
+}
+
+// AppendUnrevealedCells appends all unrevealed cells to cells, and returns the
+// extended slice
+func (board *Board) AppendUnrevealedCells(cells []*Cell) []*Cell {
+	for _, cell := range board.CellList() {
+		if !cell.isRevealed {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+// SelfNeighborList returns the cell, followed by its neighbors. The returned
+// slice is shared, and must not be modified.
+func (cell *Cell) SelfNeighborList() []*Cell {
+	board := cell.board
+	board.indexCells()
+	return board.neighborTable[board.neighborOffsets[cell.idx]:board.neighborOffsets[cell.idx+1]]
+}
+
+// NeighborList returns the cell's neighbors. The returned slice is shared, and
+// must not be modified.
+func (cell *Cell) NeighborList() []*Cell {
+	selfNeighbors := cell.SelfNeighborList()
+	if len(selfNeighbors) == 0 {
+		return selfNeighbors
+	}
+	return selfNeighbors[1:]
+}
@@ -0,0 +1,85 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Preset is a named board size and number of mines, as in the classic
+// difficulty levels
+type Preset struct {
+	Name          string
+	Width, Height uint
+	NumMines      uint
+}
+
+// Name of boards matching no preset
+const CustomPreset = "custom"
+
+var (
+	Beginner     = Preset{Name: "beginner", Width: 9, Height: 9, NumMines: 10}
+	Intermediate = Preset{Name: "intermediate", Width: 16, Height: 16, NumMines: 40}
+	Expert       = Preset{Name: "expert", Width: 30, Height: 16, NumMines: 99}
+)
+
+var Presets = []Preset{Beginner, Intermediate, Expert}
+
+// LookupPreset returns the preset with the name, ignoring case
+func LookupPreset(name string) (Preset, error) {
+	names := make([]string, 0, len(Presets))
+	for _, preset := range Presets {
+		if strings.EqualFold(preset.Name, name) {
+			return preset, nil
+		}
+		names = append(names, preset.Name)
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q (expected one of %s, or %s)",
+		name, strings.Join(names, ", "), CustomPreset)
+}
+
+// PresetName returns the canonical name of the preset a board matches, or
+// CustomPreset if it matches none
+func PresetName(width, height, numMines uint) string {
+	for _, preset := range Presets {
+		if preset.Width == width && preset.Height == height && preset.NumMines == numMines {
+			return preset.Name
+		}
+	}
+	return CustomPreset
+}
+
+// Title returns the name of the preset, capitalized for display
+func (preset Preset) Title() string {
+	return presetTitle(preset.Name)
+}
+
+func presetTitle(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// ApplyPreset sizes boards as the preset, replacing any shape, snapshot or
+// mine density
+func (config *GameConfig) ApplyPreset(preset Preset) {
+	config.Width, config.Height = preset.Width, preset.Height
+	config.NumMines = preset.NumMines
+	config.Shape = nil
+	config.Snapshot = nil
+	config.MineDensity = math.NaN()
+}
+
+// PresetName returns the canonical name of the preset boards are created as
+func (config GameConfig) PresetName() string {
+	if config.Shape != nil || config.Snapshot != nil || !math.IsNaN(config.MineDensity) {
+		return CustomPreset
+	}
+	return PresetName(config.Width, config.Height, config.NumMines)
+}
+
+// presetName returns the canonical name of the preset the board matches
+func (board *Board) presetName() string {
+	if uint(len(board.CellList())) != board.width*board.height {
+		return CustomPreset
+	}
+	return PresetName(board.width, board.height, board.numMines)
+}
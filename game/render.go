@@ -0,0 +1,133 @@
+package game
+
+import (
+	"fmt"
+	"github.com/faiface/pixel"
+	"github.com/they4kman/gosweep/util/collections"
+	xdraw "golang.org/x/image/draw"
+	"image"
+	"image/draw"
+	"sync"
+)
+
+var (
+	spritesheetImage     image.Image
+	spritesheetImageOnce sync.Once
+)
+
+// RenderConfig controls how boards are drawn into images, without a window
+type RenderConfig struct {
+	// Factor to scale each cell up by
+	Scale int
+	// Whether to show the locations of all mines, as in the editor
+	RevealMines bool
+	// Transparency of annotations
+	AnnotationAlpha float64
+	Colors          Colors
+}
+
+func NewRenderConfig() RenderConfig {
+	return RenderConfig{
+		Scale:           1,
+		RevealMines:     false,
+		AnnotationAlpha: 0.5,
+		Colors:          DefaultColors(),
+	}
+}
+
+// RenderSnapshot draws the board described by the snapshot into an image. If
+// a director is passed, the actions it would take next are drawn atop the board.
+func RenderSnapshot(snapshot *BoardSnapshot, fresh bool, director Director, config RenderConfig) (image.Image, error) {
+	board := snapshot.CreateBoard(boardConfig{}, fresh)
+	if board == nil {
+		return nil, fmt.Errorf("the snapshot's board has no cells")
+	}
+	defer board.stop()
+
+	var img image.Image
+	board.do(func() {
+		var annotations []Annotation
+		if director != nil {
+			annotations = board.previewDirector(director)
+		}
+
+		img = board.render(annotations, config)
+	})
+	return img, nil
+}
+
+// spriteBounds returns the region of the spritesheet holding the sprite for the state
+func spriteBounds(spritesheet image.Image, state CellState) image.Rectangle {
+	// Sprites are stacked top-to-bottom in the order of CellStates
+	top := cellWidth * int(state-Unrevealed)
+	return image.Rect(0, top, cellWidth, top+cellWidth).Add(spritesheet.Bounds().Min)
+}
+
+func (board *Board) render(annotations []Annotation, config RenderConfig) image.Image {
+	spritesheetImageOnce.Do(func() {
+		spritesheetImage = decodeSpritesheet()
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, int(board.width)*cellWidth, int(board.height)*cellWidth))
+
+	for _, cell := range board.CellList() {
+		state := cell.state
+		if config.RevealMines {
+			state = cell.editorState()
+		}
+
+		draw.Draw(img, cell.bounds(), spritesheetImage, spriteBounds(spritesheetImage, state).Min, draw.Src)
+	}
+
+	for _, annotation := range annotations {
+		color := config.Colors.annotation(annotation.Type).Mul(pixel.Alpha(config.AnnotationAlpha))
+		draw.Draw(img, annotation.cell.bounds(), image.NewUniform(color), image.Point{}, draw.Over)
+	}
+
+	if config.Scale <= 1 {
+		return img
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx()*config.Scale, img.Bounds().Dy()*config.Scale))
+	xdraw.NearestNeighbor.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return scaled
+}
+
+// bounds returns the region of a rendered board image the cell occupies
+func (cell *Cell) bounds() image.Rectangle {
+	x, y := int(cell.x)*cellWidth, int(cell.y)*cellWidth
+	return image.Rect(x, y, x+cellWidth, y+cellWidth)
+}
+
+// previewDirector asks the director for its next actions on the board, without
+// performing them, and returns them (along with any annotations the director
+// added itself) as annotations
+func (board *Board) previewDirector(director Director) []Annotation {
+	director.Init(board.View())
+	defer director.End()
+
+	director.CellChanges(sendCellViews(board.CellList()))
+
+	actions := make(chan CellAction, board.NumCells())
+	go director.Act(actions)
+
+	var annotations []Annotation
+	dedupedActions := make(collections.Set[CellAction])
+	for cellAction := range actions {
+		if !dedupedActions.Contains(cellAction) {
+			dedupedActions.Add(cellAction)
+			annotations = append(annotations, Annotation{
+				Type: AnnotationType(cellAction.action),
+				cell: cellAction.cell,
+			})
+		}
+	}
+
+	// Draw any highlights from the director beneath its actions
+	highlights := make([]Annotation, 0, board.directorAnnotations.Len())
+	for board.directorAnnotations.Len() > 0 {
+		highlights = append(highlights, board.directorAnnotations.PopFront())
+	}
+
+	return append(highlights, annotations...)
+}
@@ -0,0 +1,35 @@
+package game
+
+import (
+	"testing"
+)
+
+func TestRenderSnapshot(t *testing.T) {
+	snapshot, err := LoadSnapshot("seed: 1\nmode: classic\nboard: |\n  ..*\n  .1.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := NewRenderConfig()
+	config.Scale = 2
+	img, err := RenderSnapshot(snapshot, false, nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 3*cellWidth*2 || size.Y != 2*cellWidth*2 {
+		t.Errorf("rendered a %v image, expected one of 3x2 cells, at twice their size", size)
+	}
+}
+
+func TestRenderSnapshotWithoutCells(t *testing.T) {
+	for _, board := range []string{"", "  \n"} {
+		snapshot, err := LoadSnapshot("seed: 1\nmode: classic\nboard: " + board)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := RenderSnapshot(snapshot, true, nil, NewRenderConfig()); err == nil {
+			t.Errorf("rendered the blank board %q, expected an error", board)
+		}
+	}
+}
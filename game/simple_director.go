@@ -0,0 +1,48 @@
+package game
+
+// SimpleDirector decides which actions to take next by looking at the board,
+// without managing any goroutines or channels of its own. Pass it to
+// NewSimpleDirector to use it wherever a Director is expected.
+//
+// If the SimpleDirector also has an End() method, it's called when the game ends.
+type SimpleDirector interface {
+	// Return the actions to perform this step. Returning none ends the game
+	// early, if the director is being run headlessly.
+	Next(view BoardView) []CellAction
+}
+
+// SimpleDirectorFunc allows an ordinary function to be used as a SimpleDirector
+type SimpleDirectorFunc func(view BoardView) []CellAction
+
+func (next SimpleDirectorFunc) Next(view BoardView) []CellAction {
+	return next(view)
+}
+
+// NewSimpleDirector adapts the SimpleDirector to the Director interface
+func NewSimpleDirector(simple SimpleDirector) Director {
+	return &simpleDirectorAdapter{simple: simple}
+}
+
+type simpleDirectorAdapter struct {
+	BaseDirector
+
+	simple SimpleDirector
+	view   BoardView
+}
+
+func (adapter *simpleDirectorAdapter) Init(view BoardView) {
+	adapter.view = view
+}
+
+func (adapter *simpleDirectorAdapter) Act(actions chan<- CellAction) {
+	for _, action := range adapter.simple.Next(adapter.view) {
+		actions <- action
+	}
+	close(actions)
+}
+
+func (adapter *simpleDirectorAdapter) End() {
+	if ender, isEnder := adapter.simple.(interface{ End() }); isEnder {
+		ender.End()
+	}
+}
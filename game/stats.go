@@ -0,0 +1,117 @@
+package game
+
+import "time"
+
+// GameStats tallies the inputs a game was played with (by a player or a
+// director), and how efficiently they solved the board. Questioned cells count
+// as right clicks, and flag chords as chords.
+//
+// The board's 3BV is the minimum number of clicks needed to reveal all its
+// safe cells: one for each opening (a region of cells without surrounding
+// mines, along with its border), and one for each other safe cell. IOE is the
+// 3BV per click.
+type GameStats struct {
+	LeftClicks  uint `yaml:"left-clicks" json:"left_clicks"`
+	RightClicks uint `yaml:"right-clicks" json:"right_clicks"`
+	Chords      uint `yaml:"chords" json:"chords"`
+	// Inputs which changed nothing (e.g. clicks on revealed cells); these are
+	// also counted in the tallies above
+	WastedClicks uint `yaml:"wasted-clicks" json:"wasted_clicks"`
+
+	BBBV uint `yaml:"3bv" json:"3bv"`
+	// Time from the first input to the end of the game
+	Duration      time.Duration `yaml:"duration" json:"duration_ns"`
+	IOE           float64       `yaml:"ioe" json:"ioe"`
+	BBBVPerSecond float64       `yaml:"3bv-per-second" json:"3bv_per_second"`
+}
+
+// Clicks returns the total number of inputs, including wasted ones
+func (stats GameStats) Clicks() uint {
+	return stats.LeftClicks + stats.RightClicks + stats.Chords
+}
+
+// count tallies an input performing the action
+func (stats *GameStats) count(action Action) {
+	switch action {
+	case Click:
+		stats.LeftClicks++
+	case RightClick, Question:
+		stats.RightClicks++
+	case MiddleClick, FlagChord:
+		stats.Chords++
+	}
+}
+
+// finish calculates the efficiency of the game, once it's ended
+func (stats *GameStats) finish(bbbv uint, duration time.Duration) {
+	stats.BBBV = bbbv
+	stats.Duration = duration
+
+	stats.IOE, stats.BBBVPerSecond = 0, 0
+	if clicks := stats.Clicks(); clicks > 0 {
+		stats.IOE = float64(bbbv) / float64(clicks)
+	}
+	if duration > 0 {
+		stats.BBBVPerSecond = float64(bbbv) / duration.Seconds()
+	}
+}
+
+// act performs the action on the cell as an input of the game, tallying it in
+// the board's stats
+func (cell *Cell) act(action Action) {
+	board := cell.board
+	if !board.canPlay() {
+		return
+	}
+
+	if board.firstInputTime.IsZero() {
+		board.firstInputTime = time.Now()
+	}
+	board.stats.count(action)
+	numStateChanges := board.numStateChanges
+
+	switch action {
+	case Click:
+		cell.click()
+	case MiddleClick:
+		cell.middleClick()
+	case RightClick:
+		cell.rightClick()
+	case Question:
+		cell.question()
+	case FlagChord:
+		cell.flagChord()
+	}
+
+	if board.numStateChanges == numStateChanges {
+		board.stats.WastedClicks++
+	}
+}
+
+// bbbv returns the board's 3BV (see GameStats)
+func (board *Board) bbbv() uint {
+	isCovered := make([]bool, board.NumCells())
+	bbbv := uint(0)
+
+	for _, cell := range board.CellList() {
+		if !cell.isMine && cell.numMines == 0 && !isCovered[cell.idx] {
+			bbbv++
+			flood(
+				cell,
+				func(cell *Cell) {
+					isCovered[cell.idx] = true
+				},
+				func(cell *Cell) []*Cell {
+					return cell.NeighborList()
+				},
+			)
+		}
+	}
+
+	for _, cell := range board.CellList() {
+		if !cell.isMine && !isCovered[cell.idx] {
+			bbbv++
+		}
+	}
+	return bbbv
+}
@@ -0,0 +1,198 @@
+// Package minegen places mines on boards, using a PRNG and algorithm specified
+// here exactly, so a board's mines depend only on its seed, size, shape, number
+// of mines and game mode (and first click, in every mode but classic) — not on
+// the version of Go, nor on the language of the client generating them.
+//
+// Any change to the output for a given input must come with a new Version.
+//
+// # Version 1
+//
+// Cells are indexed from 0, left-to-right, top-to-bottom (idx = y*width + x),
+// over the whole width*height rectangle, including any cells left out of the
+// board's shape ("voids"). Playable cells are those which aren't voids.
+//
+// The PRNG is SplitMix64, with its 64-bit state initialized to the seed (as
+// two's complement). Each draw advances the state, then mixes it:
+//
+//	state += 0x9e3779b97f4a7c15
+//	z := state
+//	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+//	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+//	return z ^ (z >> 31)
+//
+// with all arithmetic modulo 2^64. With a seed of 0, the first three draws are
+// 0xe220a8397b1dcdaf, 0x6e789e6aa1b965f4 and 0x06c45d188009454f.
+//
+// A uniform cell index below n is drawn by discarding draws below
+// (2^64 - n) mod n, then taking the first draw which remains, modulo n.
+//
+// Mines are placed by drawing cell indexes below width*height, and discarding
+// voids and cells already mined, until the requested number of mines (capped
+// at the number of playable cells) are placed. If more than half of the
+// playable cells are to be mines, every playable cell is mined instead, and
+// mined cells drawn the same way are cleared until the requested number remain.
+//
+// In win7 mode, the first cell clicked and its playable neighbors must be
+// clear. The draws continue from the same generator: indexes are drawn,
+// discarding voids, mines and the cells around the click, and each drawn cell
+// is mined, until as many have been mined as there were mines around the
+// click; only then are the mines around the click cleared. If there are fewer
+// unmined playable cells than the number of cells around the click (including
+// the click itself), no mines are moved.
+//
+// In opening mode, mines are cleared the same way from every playable cell in
+// the 5x5 square centred on the first click, so the click and its neighbors
+// are all zeros. If there's no room to do so, they're cleared from the cells
+// around the click, as in win7 mode.
+//
+// In winxp mode, if the first cell clicked is a mine, it's moved to the
+// playable, unmined cell with the lowest index, if there is one. No draws are
+// made.
+package minegen
+
+// Version of the generation algorithm, as documented by the package
+const Version = 1
+
+// Rand is the SplitMix64 PRNG mines are placed with
+type Rand struct {
+	state uint64
+}
+
+func NewRand(seed int64) *Rand {
+	return &Rand{state: uint64(seed)}
+}
+
+func (r *Rand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Uint32n returns a uniform random number in [0, n), without modulo bias
+func (r *Rand) Uint32n(n uint32) uint32 {
+	bound := uint64(n)
+	threshold := -bound % bound
+	for {
+		if x := r.Uint64(); x >= threshold {
+			return uint32(x % bound)
+		}
+	}
+}
+
+// Field is the board mines are placed on, addressed by cell index
+type Field interface {
+	// Number of cells in the board's rectangle, including voids
+	NumCells() uint32
+	// Number of cells which aren't voids
+	NumPlayable() uint32
+	NumMines() uint32
+	IsVoid(idx uint32) bool
+	IsMine(idx uint32) bool
+	SetMine(idx uint32, isMine bool)
+}
+
+// Generator places a board's mines, drawing from a PRNG seeded with the board's
+// seed. The same Generator must be used for placing the mines and relocating
+// them on the first click.
+type Generator struct {
+	rand *Rand
+}
+
+func New(seed int64) *Generator {
+	return &Generator{rand: NewRand(seed)}
+}
+
+// PlaceMines mines n of the field's playable cells, which must all be unmined,
+// and returns the number of mines placed
+func (gen *Generator) PlaceMines(field Field, n uint32) uint32 {
+	numCells, numPlayable := field.NumCells(), field.NumPlayable()
+	if n > numPlayable {
+		n = numPlayable
+	}
+
+	if n <= numPlayable/2 {
+		for numMines := uint32(0); numMines < n; {
+			idx := gen.rand.Uint32n(numCells)
+			if !field.IsVoid(idx) && !field.IsMine(idx) {
+				field.SetMine(idx, true)
+				numMines++
+			}
+		}
+	} else {
+		for idx := uint32(0); idx < numCells; idx++ {
+			if !field.IsVoid(idx) {
+				field.SetMine(idx, true)
+			}
+		}
+
+		for numMines := numPlayable; numMines > n; {
+			idx := gen.rand.Uint32n(numCells)
+			if field.IsMine(idx) {
+				field.SetMine(idx, false)
+				numMines--
+			}
+		}
+	}
+
+	return n
+}
+
+// MoveToFirstFree moves the mine in the cell, if any, to the unmined playable
+// cell with the lowest index, and returns whether there was room to do so
+func MoveToFirstFree(field Field, idx uint32) bool {
+	if !field.IsMine(idx) {
+		return true
+	}
+
+	for freeIdx := uint32(0); freeIdx < field.NumCells(); freeIdx++ {
+		if freeIdx != idx && !field.IsVoid(freeIdx) && !field.IsMine(freeIdx) {
+			field.SetMine(freeIdx, true)
+			field.SetMine(idx, false)
+			return true
+		}
+	}
+	return false
+}
+
+// ClearAround moves any mines among the surrounding cells (e.g. the
+// first-clicked cell and its playable neighbors) elsewhere, and returns whether
+// there was room to do so
+func (gen *Generator) ClearAround(field Field, surrounding []uint32) bool {
+	isSurrounding := func(idx uint32) bool {
+		for _, surroundingIdx := range surrounding {
+			if idx == surroundingIdx {
+				return true
+			}
+		}
+		return false
+	}
+
+	numCells := field.NumCells()
+	if field.NumPlayable()-field.NumMines() < uint32(len(surrounding)) {
+		return false
+	}
+
+	numRelocations := 0
+	for _, idx := range surrounding {
+		if field.IsMine(idx) {
+			numRelocations++
+		}
+	}
+
+	// The surrounding mines are cleared only after their replacements are
+	// placed, so they aren't picked as replacements themselves
+	for relocated := 0; relocated < numRelocations; {
+		idx := gen.rand.Uint32n(numCells)
+		if !field.IsVoid(idx) && !field.IsMine(idx) && !isSurrounding(idx) {
+			field.SetMine(idx, true)
+			relocated++
+		}
+	}
+
+	for _, idx := range surrounding {
+		field.SetMine(idx, false)
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package minegen
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The expected values below are golden: they're what Version 1 generates, and
+// must never change without a new Version
+
+// testField is a board of numCells cells, with the given voids
+type testField struct {
+	numCells uint32
+	voids    map[uint32]bool
+	mines    map[uint32]bool
+}
+
+func newTestField(numCells uint32, voids ...uint32) *testField {
+	field := &testField{
+		numCells: numCells,
+		voids:    make(map[uint32]bool),
+		mines:    make(map[uint32]bool),
+	}
+	for _, idx := range voids {
+		field.voids[idx] = true
+	}
+	return field
+}
+
+func (field *testField) NumCells() uint32       { return field.numCells }
+func (field *testField) NumPlayable() uint32    { return field.numCells - uint32(len(field.voids)) }
+func (field *testField) NumMines() uint32       { return uint32(len(field.mines)) }
+func (field *testField) IsVoid(idx uint32) bool { return field.voids[idx] }
+func (field *testField) IsMine(idx uint32) bool { return field.mines[idx] }
+
+func (field *testField) SetMine(idx uint32, isMine bool) {
+	if isMine {
+		field.mines[idx] = true
+	} else {
+		delete(field.mines, idx)
+	}
+}
+
+// mineList returns the indexes of the field's mines, in order
+func (field *testField) mineList() []uint32 {
+	mines := make([]uint32, 0, len(field.mines))
+	for idx := uint32(0); idx < field.numCells; idx++ {
+		if field.mines[idx] {
+			mines = append(mines, idx)
+		}
+	}
+	return mines
+}
+
+func TestRand(t *testing.T) {
+	tests := []struct {
+		seed  int64
+		draws []uint64
+	}{
+		// As documented by the package
+		{0, []uint64{0xe220a8397b1dcdaf, 0x6e789e6aa1b965f4, 0x06c45d188009454f}},
+		{-1, []uint64{0xe4d971771b652c20, 0xe99ff867dbf682c9, 0x382ff84cb27281e9}},
+	}
+
+	for _, test := range tests {
+		r := NewRand(test.seed)
+		for i, expected := range test.draws {
+			if draw := r.Uint64(); draw != expected {
+				t.Errorf("seed %d, draw %d: %#x, expected %#x", test.seed, i, draw, expected)
+			}
+		}
+	}
+}
+
+func TestRandUint32n(t *testing.T) {
+	r := NewRand(42)
+	draws := make([]uint32, 10)
+	for i := range draws {
+		draws[i] = r.Uint32n(81)
+	}
+
+	expected := []uint32{46, 46, 45, 0, 25, 15, 19, 23, 64, 38}
+	if !reflect.DeepEqual(draws, expected) {
+		t.Errorf("drew %v, expected %v", draws, expected)
+	}
+}
+
+func TestPlaceMines(t *testing.T) {
+	tests := []struct {
+		name     string
+		seed     int64
+		field    *testField
+		numMines uint32
+		mines    []uint32
+	}{
+		{"beginner", 42, newTestField(81), 10, []uint32{0, 15, 19, 23, 25, 38, 45, 46, 64, 80}},
+		{"voids", -5, newTestField(25, 0, 4, 12, 20, 24), 8, []uint32{5, 7, 8, 10, 14, 17, 19, 21}},
+		{"dense", 7, newTestField(81), 60, []uint32{
+			0, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 15, 16, 18, 19, 21, 22, 23, 24, 26, 27, 29, 30, 33, 34, 35, 36, 38,
+			40, 42, 44, 45, 46, 47, 49, 50, 51, 52, 53, 54, 55, 56, 57, 58, 59, 60, 62, 64, 66, 67, 68, 69, 70, 71, 73, 74, 76, 77,
+		}},
+		{"capped", 1, newTestField(4, 1), 9, []uint32{0, 2, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			numPlaced := New(test.seed).PlaceMines(test.field, test.numMines)
+			if numPlaced != uint32(len(test.mines)) {
+				t.Errorf("placed %d mines, expected %d", numPlaced, len(test.mines))
+			}
+			if mines := test.field.mineList(); !reflect.DeepEqual(mines, test.mines) {
+				t.Errorf("placed mines at %v, expected %v", mines, test.mines)
+			}
+		})
+	}
+}
+
+func TestClearAround(t *testing.T) {
+	field := newTestField(81)
+	gen := New(42)
+	gen.PlaceMines(field, 10)
+
+	// Around (1, 5), which is mined, along with 2 of its neighbors. The
+	// replacements are drawn from the same generator.
+	surrounding := []uint32{36, 37, 38, 45, 46, 47, 54, 55, 56}
+	if !gen.ClearAround(field, surrounding) {
+		t.Fatal("expected there to be room to clear the cells")
+	}
+
+	expected := []uint32{0, 15, 19, 23, 25, 61, 64, 68, 73, 80}
+	if mines := field.mineList(); !reflect.DeepEqual(mines, expected) {
+		t.Errorf("mines at %v, expected %v", mines, expected)
+	}
+
+	// Without room, nothing moves
+	full := newTestField(9)
+	New(1).PlaceMines(full, 4)
+	before := full.mineList()
+	if New(1).ClearAround(full, []uint32{0, 1, 2, 3, 4, 5}) {
+		t.Error("expected there to be no room to clear the cells")
+	}
+	if mines := full.mineList(); !reflect.DeepEqual(mines, before) {
+		t.Errorf("mines moved to %v, expected them left at %v", mines, before)
+	}
+}
+
+func TestMoveToFirstFree(t *testing.T) {
+	field := newTestField(9, 0)
+	for _, idx := range []uint32{1, 2, 5} {
+		field.SetMine(idx, true)
+	}
+
+	if !MoveToFirstFree(field, 5) {
+		t.Fatal("expected there to be room to move the mine")
+	}
+	if mines, expected := field.mineList(), []uint32{1, 2, 3}; !reflect.DeepEqual(mines, expected) {
+		t.Errorf("mines at %v, expected %v", mines, expected)
+	}
+
+	if !MoveToFirstFree(field, 4) {
+		t.Error("expected moving from an unmined cell to succeed")
+	}
+
+	for idx := uint32(1); idx < 9; idx++ {
+		field.SetMine(idx, true)
+	}
+	if MoveToFirstFree(field, 4) {
+		t.Error("expected there to be no room to move the mine")
+	}
+}
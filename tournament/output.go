@@ -0,0 +1,85 @@
+package tournament
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable writes the standings and head-to-head results as aligned text
+func (results *Results) WriteTable(out io.Writer) error {
+	table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(table, "Rank\tDirector\tWins\tGames\tWin rate\t95% CI\tRevealed\tMean time")
+	for _, standing := range results.Standings {
+		fmt.Fprintf(table, "%d\t%s\t%d\t%d\t%.1f%%\t%.1f%% – %.1f%%\t%.1f%%\t%s\n",
+			standing.Rank, standing.Director, standing.NumWins, standing.NumGames,
+			100*standing.WinRate, 100*standing.WinRateLow, 100*standing.WinRateHigh,
+			100*standing.MeanRevealed, standing.MeanDuration.Round(time.Microsecond))
+	}
+
+	fmt.Fprintln(table)
+	fmt.Fprintln(table, "Director\tOpponent\tWins\tLosses\tBoth won\tBoth lost")
+	for _, result := range results.HeadToHead {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%d\t%d\n",
+			result.Director, result.Opponent, result.NumWins, result.NumLosses, result.NumBothWon, result.NumBothLost)
+	}
+
+	return table.Flush()
+}
+
+// WriteCSV writes the standings, then a blank line, then the head-to-head
+// results, as CSV
+func (results *Results) WriteCSV(out io.Writer) error {
+	writer := csv.NewWriter(out)
+
+	writer.Write([]string{"rank", "director", "wins", "games", "win_rate", "win_rate_low", "win_rate_high", "mean_revealed", "mean_duration_ms"})
+	for _, standing := range results.Standings {
+		writer.Write([]string{
+			strconv.Itoa(standing.Rank),
+			standing.Director,
+			strconv.Itoa(standing.NumWins),
+			strconv.Itoa(standing.NumGames),
+			formatFloat(standing.WinRate),
+			formatFloat(standing.WinRateLow),
+			formatFloat(standing.WinRateHigh),
+			formatFloat(standing.MeanRevealed),
+			formatFloat(float64(standing.MeanDuration) / float64(time.Millisecond)),
+		})
+	}
+
+	writer.Flush()
+	if _, err := io.WriteString(out, "\n"); err != nil {
+		return err
+	}
+
+	writer.Write([]string{"director", "opponent", "wins", "losses", "both_won", "both_lost"})
+	for _, result := range results.HeadToHead {
+		writer.Write([]string{
+			result.Director,
+			result.Opponent,
+			strconv.Itoa(result.NumWins),
+			strconv.Itoa(result.NumLosses),
+			strconv.Itoa(result.NumBothWon),
+			strconv.Itoa(result.NumBothLost),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteJSON writes the standings, head-to-head results and every game as JSON
+func (results *Results) WriteJSON(out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
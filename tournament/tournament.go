@@ -0,0 +1,328 @@
+// Package tournament plays directors against each other, on the same boards
+// and seeds, so they may be compared objectively
+package tournament
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"github.com/they4kman/gosweep/game"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// z-score of the confidence intervals given for win rates (95%)
+const confidenceZ = 1.96
+
+// Entrant is a director taking part in the tournament
+type Entrant struct {
+	Name string
+	// Creates a fresh director for each game
+	NewDirector func() (game.Director, error)
+}
+
+// Board describes the boards played in the tournament
+type Board struct {
+	Width, Height uint
+	NumMines      uint
+}
+
+func (board Board) String() string {
+	return fmt.Sprintf("%dx%d:%d", board.Width, board.Height, board.NumMines)
+}
+
+// ParseBoard parses a board description of the form WIDTHxHEIGHT:MINES, or
+// the name of a preset (e.g. expert)
+func ParseBoard(spec string) (Board, error) {
+	if preset, err := game.LookupPreset(spec); err == nil {
+		return Board{Width: preset.Width, Height: preset.Height, NumMines: preset.NumMines}, nil
+	}
+
+	var board Board
+	if _, err := fmt.Sscanf(spec, "%dx%d:%d", &board.Width, &board.Height, &board.NumMines); err != nil {
+		return Board{}, fmt.Errorf("invalid board %q (expected WIDTHxHEIGHT:MINES, or a preset): %w", spec, err)
+	}
+	if board.Width == 0 || board.Height == 0 {
+		return Board{}, fmt.Errorf("invalid board %q: boards must have cells", spec)
+	}
+	return board, nil
+}
+
+type Config struct {
+	Entrants []Entrant
+	Boards   []Board
+
+	// Every board is played once with each seed, from FirstSeed onward
+	FirstSeed int64
+	NumSeeds  int
+
+	Mode  game.GameMode
+	Lives uint
+
+	// Number of games played at once
+	Parallelism int
+}
+
+// Game is the result of one entrant playing one board with one seed
+type Game struct {
+	Director string `json:"director"`
+	Board    string `json:"board"`
+	Seed     int64  `json:"seed"`
+
+	Won bool `json:"won"`
+	// Whether the director gave up before the game ended
+	GaveUp bool `json:"gave_up"`
+	// Fraction of non-mine cells revealed
+	Revealed float64       `json:"revealed"`
+	NumSteps int           `json:"steps"`
+	Duration time.Duration `json:"duration_ns"`
+	// Actions the director took, and how efficiently
+	Stats game.GameStats `json:"stats"`
+}
+
+// Standing summarizes all the games of one entrant
+type Standing struct {
+	Rank     int    `json:"rank"`
+	Director string `json:"director"`
+
+	NumGames int     `json:"games"`
+	NumWins  int     `json:"wins"`
+	WinRate  float64 `json:"win_rate"`
+	// Wilson score interval of the win rate
+	WinRateLow  float64 `json:"win_rate_low"`
+	WinRateHigh float64 `json:"win_rate_high"`
+
+	MeanRevealed float64       `json:"mean_revealed"`
+	MeanDuration time.Duration `json:"mean_duration_ns"`
+}
+
+// HeadToHead compares two entrants over the games both played with the same
+// board and seed
+type HeadToHead struct {
+	Director    string `json:"director"`
+	Opponent    string `json:"opponent"`
+	NumWins     int    `json:"wins"`
+	NumLosses   int    `json:"losses"`
+	NumBothWon  int    `json:"both_won"`
+	NumBothLost int    `json:"both_lost"`
+}
+
+type Results struct {
+	Standings  []Standing   `json:"standings"`
+	HeadToHead []HeadToHead `json:"head_to_head"`
+	Games      []Game       `json:"games"`
+}
+
+// Run plays every entrant on every board with every seed
+func Run(config Config) (*Results, error) {
+	if len(config.Entrants) == 0 {
+		return nil, fmt.Errorf("no directors were entered")
+	}
+	names := make(map[string]bool)
+	for _, entrant := range config.Entrants {
+		if names[entrant.Name] {
+			return nil, fmt.Errorf("director %s was entered more than once", entrant.Name)
+		}
+		names[entrant.Name] = true
+	}
+	if len(config.Boards) == 0 || config.NumSeeds <= 0 {
+		return nil, fmt.Errorf("no games to play")
+	}
+
+	// Games are listed up front, so results come out in the same order,
+	// however many are played at once
+	games := make([]Game, 0, len(config.Entrants)*len(config.Boards)*config.NumSeeds)
+	entrants := make([]Entrant, 0, cap(games))
+	boards := make([]Board, 0, cap(games))
+	for _, board := range config.Boards {
+		for seed := config.FirstSeed; seed < config.FirstSeed+int64(config.NumSeeds); seed++ {
+			for _, entrant := range config.Entrants {
+				games = append(games, Game{Director: entrant.Name, Board: board.String(), Seed: seed})
+				entrants = append(entrants, entrant)
+				boards = append(boards, board)
+			}
+		}
+	}
+
+	parallelism := config.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	gameIdxs := make(chan int)
+	errs := make(chan error, len(games))
+	wg := sync.WaitGroup{}
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for gameIdx := range gameIdxs {
+				if err := config.play(&games[gameIdx], entrants[gameIdx], boards[gameIdx]); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+
+	for gameIdx := range games {
+		gameIdxs <- gameIdx
+	}
+	close(gameIdxs)
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+
+	return &Results{
+		Standings:  standings(config.Entrants, games),
+		HeadToHead: headToHead(config.Entrants, games),
+		Games:      games,
+	}, nil
+}
+
+func (config Config) play(result *Game, entrant Entrant, board Board) error {
+	director, err := entrant.NewDirector()
+	if err != nil {
+		return fmt.Errorf("unable to create director %s: %w", entrant.Name, err)
+	}
+
+	gameConfig := game.NewGameConfig()
+	gameConfig.Width, gameConfig.Height = board.Width, board.Height
+	gameConfig.NumMines = board.NumMines
+	gameConfig.Mode = config.Mode
+	gameConfig.Lives = config.Lives
+	gameConfig.Seed = result.Seed
+	gameConfig.Director = director
+
+	gameResult, err := game.PlayHeadless(gameConfig)
+	if err != nil {
+		return fmt.Errorf("unable to play %s on %s with seed %d: %w", entrant.Name, board, result.Seed, err)
+	}
+
+	result.Won = gameResult.State == game.Won
+	result.GaveUp = gameResult.State != game.Won && gameResult.State != game.Lost
+	if gameResult.NumSafeCells > 0 {
+		result.Revealed = float64(gameResult.NumRevealed) / float64(gameResult.NumSafeCells)
+	}
+	result.NumSteps = gameResult.NumSteps
+	result.Duration = gameResult.Duration
+	result.Stats = gameResult.Stats
+
+	logrus.Infof("%s on %s with seed %d: won=%v", entrant.Name, board, result.Seed, result.Won)
+	return nil
+}
+
+func standings(entrants []Entrant, games []Game) []Standing {
+	standings := make([]Standing, len(entrants))
+	standingIdxs := make(map[string]int)
+	for i, entrant := range entrants {
+		standings[i].Director = entrant.Name
+		standingIdxs[entrant.Name] = i
+	}
+
+	for _, played := range games {
+		standing := &standings[standingIdxs[played.Director]]
+		standing.NumGames++
+		if played.Won {
+			standing.NumWins++
+		}
+		standing.MeanRevealed += played.Revealed
+		standing.MeanDuration += played.Duration
+	}
+
+	for i := range standings {
+		standing := &standings[i]
+		if standing.NumGames == 0 {
+			continue
+		}
+
+		standing.WinRate = float64(standing.NumWins) / float64(standing.NumGames)
+		standing.WinRateLow, standing.WinRateHigh = wilsonInterval(standing.NumWins, standing.NumGames)
+		standing.MeanRevealed /= float64(standing.NumGames)
+		standing.MeanDuration /= time.Duration(standing.NumGames)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.WinRate != b.WinRate {
+			return a.WinRate > b.WinRate
+		}
+		return a.MeanRevealed > b.MeanRevealed
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+
+	return standings
+}
+
+// wilsonInterval returns the bounds of the confidence interval of a win rate
+func wilsonInterval(numWins, numGames int) (float64, float64) {
+	n := float64(numGames)
+	p := float64(numWins) / n
+	z2 := confidenceZ * confidenceZ
+
+	denominator := 1 + z2/n
+	center := (p + z2/(2*n)) / denominator
+	margin := confidenceZ * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / denominator
+
+	low, high := math.Max(0, center-margin), math.Min(1, center+margin)
+
+	// Avoid rounding errors at the extremes
+	if numWins == 0 {
+		low = 0
+	}
+	if numWins == numGames {
+		high = 1
+	}
+	return low, high
+}
+
+func headToHead(entrants []Entrant, games []Game) []HeadToHead {
+	type boardSeed struct {
+		board string
+		seed  int64
+	}
+
+	wins := make(map[string]map[boardSeed]bool)
+	for _, played := range games {
+		if wins[played.Director] == nil {
+			wins[played.Director] = make(map[boardSeed]bool)
+		}
+		wins[played.Director][boardSeed{played.Board, played.Seed}] = played.Won
+	}
+
+	results := make([]HeadToHead, 0)
+	for _, entrant := range entrants {
+		for _, opponent := range entrants {
+			if entrant.Name == opponent.Name {
+				continue
+			}
+
+			result := HeadToHead{Director: entrant.Name, Opponent: opponent.Name}
+			for key, won := range wins[entrant.Name] {
+				opponentWon, opponentPlayed := wins[opponent.Name][key]
+				if !opponentPlayed {
+					continue
+				}
+
+				switch {
+				case won && opponentWon:
+					result.NumBothWon++
+				case won:
+					result.NumWins++
+				case opponentWon:
+					result.NumLosses++
+				default:
+					result.NumBothLost++
+				}
+			}
+			results = append(results, result)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,115 @@
+package tournament
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBoard(t *testing.T) {
+	tests := []struct {
+		spec  string
+		board Board
+		error bool
+	}{
+		{"expert", Board{Width: 30, Height: 16, NumMines: 99}, false},
+		{"20x10:35", Board{Width: 20, Height: 10, NumMines: 35}, false},
+		{"20x10", Board{}, true},
+		{"0x10:5", Board{}, true},
+		{"huge", Board{}, true},
+	}
+
+	for _, test := range tests {
+		board, err := ParseBoard(test.spec)
+		if test.error {
+			if err == nil {
+				t.Errorf("parsed %q as %v, expected an error", test.spec, board)
+			}
+		} else if err != nil || board != test.board {
+			t.Errorf("parsed %q as %v (%v), expected %v", test.spec, board, err, test.board)
+		}
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		numWins, numGames int
+		low, high         float64
+	}{
+		{0, 10, 0, 0.2775},
+		{5, 10, 0.2366, 0.7634},
+		{10, 10, 0.7225, 1},
+		{1, 1, 0.2065, 1},
+		{60, 100, 0.5020, 0.6906},
+	}
+
+	for _, test := range tests {
+		low, high := wilsonInterval(test.numWins, test.numGames)
+		if math.Abs(low-test.low) > 1e-4 || math.Abs(high-test.high) > 1e-4 {
+			t.Errorf("%d/%d: interval is [%.4f, %.4f], expected [%.4f, %.4f]",
+				test.numWins, test.numGames, low, high, test.low, test.high)
+		}
+	}
+}
+
+func TestStandings(t *testing.T) {
+	entrants := []Entrant{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "idle"}}
+	games := []Game{
+		{Director: "a", Won: false, Revealed: 0.5, Duration: 2 * time.Second},
+		{Director: "a", Won: true, Revealed: 1, Duration: 4 * time.Second},
+		{Director: "b", Won: true, Revealed: 1},
+		{Director: "b", Won: true, Revealed: 1},
+		{Director: "c", Won: false, Revealed: 0.25},
+		{Director: "c", Won: true, Revealed: 1},
+	}
+
+	standings := standings(entrants, games)
+
+	var ranking []string
+	for i, standing := range standings {
+		ranking = append(ranking, standing.Director)
+		if standing.Rank != i+1 {
+			t.Errorf("%s is ranked %d, expected %d", standing.Director, standing.Rank, i+1)
+		}
+	}
+	// Equal win rates are ranked by the fraction of cells revealed
+	if expected := []string{"b", "a", "c", "idle"}; !reflect.DeepEqual(ranking, expected) {
+		t.Errorf("ranked %v, expected %v", ranking, expected)
+	}
+
+	a := standings[1]
+	if a.NumGames != 2 || a.NumWins != 1 || a.WinRate != 0.5 || a.MeanRevealed != 0.75 || a.MeanDuration != 3*time.Second {
+		t.Errorf("summarized a as %+v", a)
+	}
+	if low, high := wilsonInterval(1, 2); a.WinRateLow != low || a.WinRateHigh != high {
+		t.Errorf("a's win rate interval is [%f, %f], expected [%f, %f]", a.WinRateLow, a.WinRateHigh, low, high)
+	}
+	if idle := standings[3]; idle.NumGames != 0 || idle.WinRate != 0 {
+		t.Errorf("summarized idle, which played no games, as %+v", idle)
+	}
+}
+
+func TestHeadToHead(t *testing.T) {
+	entrants := []Entrant{{Name: "a"}, {Name: "b"}}
+	games := []Game{
+		{Director: "a", Board: "expert", Seed: 1, Won: true},
+		{Director: "b", Board: "expert", Seed: 1, Won: true},
+		{Director: "a", Board: "expert", Seed: 2, Won: true},
+		{Director: "b", Board: "expert", Seed: 2, Won: false},
+		{Director: "a", Board: "expert", Seed: 3, Won: false},
+		{Director: "b", Board: "expert", Seed: 3, Won: false},
+		{Director: "a", Board: "beginner", Seed: 3, Won: false},
+		{Director: "b", Board: "beginner", Seed: 3, Won: true},
+		// Games only one entrant played aren't compared
+		{Director: "a", Board: "expert", Seed: 4, Won: true},
+	}
+
+	expected := []HeadToHead{
+		{Director: "a", Opponent: "b", NumWins: 1, NumLosses: 1, NumBothWon: 1, NumBothLost: 1},
+		{Director: "b", Opponent: "a", NumWins: 1, NumLosses: 1, NumBothWon: 1, NumBothLost: 1},
+	}
+	if results := headToHead(entrants, games); !reflect.DeepEqual(results, expected) {
+		t.Errorf("compared as %+v, expected %+v", results, expected)
+	}
+}